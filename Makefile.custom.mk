@@ -0,0 +1,8 @@
+##@ Code generation
+
+CONTROLLER_GEN_VERSION := v0.7.0
+
+.PHONY: generate
+generate: ## Generates the deepcopy functions of the app-checker CRDs.
+	@echo "====> $@"
+	go run sigs.k8s.io/controller-tools/cmd/controller-gen@$(CONTROLLER_GEN_VERSION) object paths=./pkg/apis/...
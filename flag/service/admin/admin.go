@@ -0,0 +1,5 @@
+package admin
+
+type Admin struct {
+	Token string
+}
@@ -0,0 +1,6 @@
+package catalog
+
+type Catalog struct {
+	Rules      string
+	Validation Validation
+}
@@ -0,0 +1,6 @@
+package catalog
+
+type Validation struct {
+	Enabled string
+	Wait    string
+}
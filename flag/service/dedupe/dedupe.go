@@ -0,0 +1,6 @@
+package dedupe
+
+type Dedupe struct {
+	Persistent string
+	TTL        string
+}
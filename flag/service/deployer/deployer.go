@@ -0,0 +1,7 @@
+package deployer
+
+type Deployer struct {
+	MaxTimeout string
+	Rollback   string
+	Timeout    string
+}
@@ -0,0 +1,8 @@
+package gc
+
+type GC struct {
+	DryRun   string
+	Enabled  string
+	Interval string
+	TTL      string
+}
@@ -0,0 +1,7 @@
+package github
+
+type App struct {
+	ID             string
+	InstallationID string
+	PrivateKeyFile string
+}
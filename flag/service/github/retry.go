@@ -0,0 +1,7 @@
+package github
+
+type Retry struct {
+	Budget         string
+	MaxAttempts    string
+	ResendInterval string
+}
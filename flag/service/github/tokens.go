@@ -1,6 +1,10 @@
 package github
 
 type Github struct {
-	GitHubToken      string
-	WebhookSecretKey string
+	App                      App
+	AutoInactive             string
+	GitHubToken              string
+	PreviousWebhookSecretKey string
+	Retry                    Retry
+	WebhookSecretKey         string
 }
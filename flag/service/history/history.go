@@ -0,0 +1,5 @@
+package history
+
+type History struct {
+	Retention string
+}
@@ -1,6 +1,7 @@
 package installation
 
 type Installation struct {
-	Environment    string
-	WebhookBaseURL string
+	Environment            string
+	EnvironmentURLTemplate string
+	WebhookBaseURL         string
 }
@@ -0,0 +1,11 @@
+package repository
+
+type Repository struct {
+	Allow Patterns
+	Deny  Patterns
+}
+
+type Patterns struct {
+	Names  string
+	Owners string
+}
@@ -3,13 +3,32 @@ package service
 import (
 	"github.com/giantswarm/operatorkit/flag/service/kubernetes"
 
+	"github.com/giantswarm/app-checker/flag/service/admin"
+	"github.com/giantswarm/app-checker/flag/service/catalog"
+	"github.com/giantswarm/app-checker/flag/service/dedupe"
+	"github.com/giantswarm/app-checker/flag/service/deployer"
+	"github.com/giantswarm/app-checker/flag/service/gc"
 	"github.com/giantswarm/app-checker/flag/service/github"
+	"github.com/giantswarm/app-checker/flag/service/history"
 	"github.com/giantswarm/app-checker/flag/service/installation"
+	"github.com/giantswarm/app-checker/flag/service/repository"
+	"github.com/giantswarm/app-checker/flag/service/store"
+	"github.com/giantswarm/app-checker/flag/service/worker"
 )
 
 // Service is an intermediate data structure for command line configuration flags.
 type Service struct {
+	Admin        admin.Admin
+	Catalog      catalog.Catalog
+	Dedupe       dedupe.Dedupe
+	Deployer     deployer.Deployer
+	DryRun       string
+	GC           gc.GC
+	History      history.History
 	Installation installation.Installation
 	Kubernetes   kubernetes.Kubernetes
 	Github       github.Github
+	Repository   repository.Repository
+	Store        store.Store
+	Worker       worker.Worker
 }
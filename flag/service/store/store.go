@@ -0,0 +1,5 @@
+package store
+
+type Store struct {
+	Namespace string
+}
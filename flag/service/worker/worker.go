@@ -0,0 +1,8 @@
+package worker
+
+type Worker struct {
+	Count           string
+	DrainAddress    string
+	QueueSize       string
+	ShutdownTimeout string
+}
@@ -7,6 +7,7 @@ require (
 	github.com/giantswarm/apiextensions/v3 v3.26.0
 	github.com/giantswarm/app/v4 v4.13.0
 	github.com/giantswarm/k8sclient/v5 v5.11.0
+	github.com/giantswarm/k8smetadata v0.3.0
 	github.com/giantswarm/microendpoint v0.2.0
 	github.com/giantswarm/microerror v0.3.0
 	github.com/giantswarm/microkit v0.2.2
@@ -14,10 +15,16 @@ require (
 	github.com/giantswarm/operatorkit v1.2.0
 	github.com/go-kit/kit v0.10.0
 	github.com/google/go-github/v32 v32.1.0
+	github.com/gorilla/mux v1.8.0
+	github.com/prometheus/client_golang v1.7.1
 	github.com/spf13/viper v1.7.1
 	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
+	golang.org/x/sync v0.0.0-20201020160332-67f06af15bc9
+	k8s.io/api v0.18.19
 	k8s.io/apimachinery v0.18.19
 	k8s.io/client-go v0.18.19
+	sigs.k8s.io/controller-runtime v0.6.4
+	sigs.k8s.io/yaml v1.2.0
 )
 
 replace (
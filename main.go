@@ -2,6 +2,10 @@ package main
 
 import (
 	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	applicationv1alpha1 "github.com/giantswarm/apiextensions/v3/pkg/apis/application/v1alpha1"
 	"github.com/giantswarm/k8sclient/v5/pkg/k8sclient"
@@ -14,9 +18,12 @@ import (
 	"k8s.io/client-go/rest"
 
 	"github.com/giantswarm/app-checker/flag"
+	"github.com/giantswarm/app-checker/pkg/apimetrics"
+	appcheckerv1alpha1 "github.com/giantswarm/app-checker/pkg/apis/appchecker/v1alpha1"
 	"github.com/giantswarm/app-checker/pkg/project"
 	"github.com/giantswarm/app-checker/server"
 	"github.com/giantswarm/app-checker/service"
+	"github.com/giantswarm/app-checker/service/filter"
 )
 
 var (
@@ -66,6 +73,8 @@ func mainE(ctx context.Context) error {
 			if err != nil {
 				panic(err)
 			}
+
+			restConfig.Wrap(apimetrics.NewKubernetesTransport)
 		}
 
 		var k8sClient k8sclient.Interface
@@ -73,6 +82,7 @@ func mainE(ctx context.Context) error {
 			c := k8sclient.ClientsConfig{
 				Logger: newLogger,
 				SchemeBuilder: k8sclient.SchemeBuilder{
+					appcheckerv1alpha1.AddToScheme,
 					applicationv1alpha1.AddToScheme,
 				},
 
@@ -88,7 +98,8 @@ func mainE(ctx context.Context) error {
 		var newService *service.Service
 		{
 			c := service.Config{
-				Logger: newLogger,
+				K8sClient: k8sClient,
+				Logger:    newLogger,
 
 				Flag:  f,
 				Viper: v,
@@ -105,10 +116,9 @@ func mainE(ctx context.Context) error {
 		var newServer microserver.Server
 		{
 			c := server.Config{
-				Flag:      f,
-				K8sClient: k8sClient,
-				Logger:    newLogger,
-				Service:   newService,
+				Flag:    f,
+				Logger:  newLogger,
+				Service: newService,
 
 				Viper: v,
 			}
@@ -119,6 +129,26 @@ func mainE(ctx context.Context) error {
 			}
 		}
 
+		// microkit only uses the configuration of our custom server, so its
+		// boot logic is triggered here. microkit exits shortly after SIGTERM
+		// without shutting down our server. Queued and in-flight deployments
+		// are drained before, through the drain endpoint the preStop hook of
+		// the pod calls.
+		go newServer.Boot()
+
+		// Without preStop hook, e.g. when running outside Kubernetes, our
+		// server is shut down on SIGTERM as well. The pool stops accepting
+		// deployments right away. Jobs still in flight when microkit exits
+		// stay persisted and are resumed on the next boot.
+		go func() {
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+			<-signals
+
+			newServer.Shutdown()
+		}()
+
 		return newServer
 	}
 
@@ -144,9 +174,33 @@ func mainE(ctx context.Context) error {
 
 	daemonCommand := newCommand.DaemonCommand().CobraCommand()
 
+	daemonCommand.PersistentFlags().String(f.Service.Admin.Token, "", "Bearer token of the admin API listing, inspecting, retrying and manually creating deployments. When empty the admin API is disabled.")
+	daemonCommand.PersistentFlags().String(f.Service.Catalog.Rules, "", "Ordered YAML list of rules selecting the catalog apps get deployed from. When empty the default rules are used.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Catalog.Validation.Enabled, true, "Whether to validate that the deployed version is published in the catalog before creating or updating the App CR.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Catalog.Validation.Wait, 0, "Time to wait for a missing version to get published to the catalog before failing the deployment.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Dedupe.Persistent, false, "Whether to persist handled webhook deliveries in ConfigMaps so redeliveries are recognized across restarts.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Dedupe.TTL, 24*time.Hour, "Time handled webhook deliveries are kept to recognize their redeliveries.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Deployer.MaxTimeout, 30*time.Minute, "Upper bound of the timeout deployments can request in their payload. Deployments requesting more fail.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Deployer.Rollback, false, "Whether to restore the previous App CR spec when a deployment fails, unless the deployment payload requests otherwise.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Deployer.Timeout, 1*time.Minute, "Time to wait for a deployed app to settle unless the deployment payload requests otherwise.")
+	daemonCommand.PersistentFlags().Bool(f.Service.DryRun, false, "Whether to only compute and report the App CR changes of deployments without applying them.")
+	daemonCommand.PersistentFlags().Bool(f.Service.GC.DryRun, false, "Whether to only log the stale App CRs which would be removed.")
+	daemonCommand.PersistentFlags().Bool(f.Service.GC.Enabled, false, "Whether to remove App CRs of deleted refs and of refs not deployed to within the TTL.")
+	daemonCommand.PersistentFlags().Duration(f.Service.GC.Interval, 1*time.Hour, "Interval in which stale App CRs are collected.")
+	daemonCommand.PersistentFlags().Duration(f.Service.GC.TTL, 0, "Time after the last deployment of a ref other than the default branch after which its App CRs are removed. Zero only removes App CRs of deleted refs.")
+	daemonCommand.PersistentFlags().Int64(f.Service.Github.App.ID, 0, "ID of the GitHub App to authenticate as. When empty the OAuth token is used.")
+	daemonCommand.PersistentFlags().Int64(f.Service.Github.App.InstallationID, 0, "ID of the GitHub App installation to authenticate as. When empty the installation of each webhook event is used.")
+	daemonCommand.PersistentFlags().String(f.Service.Github.App.PrivateKeyFile, "", "Path of the PEM encoded private key of the GitHub App.")
+	daemonCommand.PersistentFlags().Bool(f.Service.Github.AutoInactive, true, "Whether to mark older successful deployments of the same app inactive once a deployment succeeds.")
 	daemonCommand.PersistentFlags().String(f.Service.Github.GitHubToken, "", "OAuth token for authenticating against GitHub. Needs 'repo_deployment' scope.\"")
+	daemonCommand.PersistentFlags().String(f.Service.Github.PreviousWebhookSecretKey, "", "Previous secret key still accepted for webhook payload signatures while rotating secrets.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Github.Retry.Budget, 1*time.Minute, "Time spent at most retrying to create a GitHub deployment status before re-sending it in the background.")
+	daemonCommand.PersistentFlags().Int(f.Service.Github.Retry.MaxAttempts, 5, "Attempts to create a GitHub deployment status before re-sending it in the background.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Github.Retry.ResendInterval, 5*time.Minute, "Interval in which GitHub deployment statuses which could not be created are re-sent.")
 	daemonCommand.PersistentFlags().String(f.Service.Github.WebhookSecretKey, "", "Secret key to decrypt webhook payload.\"")
+	daemonCommand.PersistentFlags().Duration(f.Service.History.Retention, 30*24*time.Hour, "Time AppDeployment CRs are kept after their deployment finished. Zero keeps them forever.")
 	daemonCommand.PersistentFlags().String(f.Service.Installation.Environment, "", "Environment name that app-checker is running in.")
+	daemonCommand.PersistentFlags().String(f.Service.Installation.EnvironmentURLTemplate, "", "Go template rendering the environment URL of GitHub deployment statuses, e.g. '{{ .WebhookBaseURL }}/apps/{{ .AppCRName }}'. When empty no environment URL is reported.")
 	daemonCommand.PersistentFlags().String(f.Service.Installation.WebhookBaseURL, "", "Webhook address that this operator listening to.")
 
 	daemonCommand.PersistentFlags().String(f.Service.Kubernetes.Address, "http://127.0.0.1:6443", "Address used to connect to Kubernetes. When empty in-cluster config is created.")
@@ -156,6 +210,18 @@ func mainE(ctx context.Context) error {
 	daemonCommand.PersistentFlags().String(f.Service.Kubernetes.TLS.CrtFile, "", "Certificate file path to use to authenticate with Kubernetes.")
 	daemonCommand.PersistentFlags().String(f.Service.Kubernetes.TLS.KeyFile, "", "Key file path to use to authenticate with Kubernetes.")
 
+	daemonCommand.PersistentFlags().StringSlice(f.Service.Repository.Allow.Names, nil, "Patterns of repository names to deploy for. When empty all repositories are allowed.")
+	daemonCommand.PersistentFlags().StringSlice(f.Service.Repository.Allow.Owners, nil, "Patterns of repository owners to deploy for. When empty all owners are allowed.")
+	daemonCommand.PersistentFlags().StringSlice(f.Service.Repository.Deny.Names, filter.DefaultDenyNames(), "Patterns of repository names to ignore deployments of.")
+	daemonCommand.PersistentFlags().StringSlice(f.Service.Repository.Deny.Owners, nil, "Patterns of repository owners to ignore deployments of.")
+
+	daemonCommand.PersistentFlags().String(f.Service.Store.Namespace, "giantswarm", "Namespace of the ConfigMaps persisting accepted deployment jobs.")
+
+	daemonCommand.PersistentFlags().Int(f.Service.Worker.Count, 5, "Number of deployment events processed concurrently.")
+	daemonCommand.PersistentFlags().String(f.Service.Worker.DrainAddress, "127.0.0.1:8001", "Loopback address serving POST /drain, which drains queued and in-flight deployment events before shutdown. When empty it is not served.")
+	daemonCommand.PersistentFlags().Int(f.Service.Worker.QueueSize, 100, "Number of deployment events queued before further webhook deliveries are rejected.")
+	daemonCommand.PersistentFlags().Duration(f.Service.Worker.ShutdownTimeout, 25*time.Second, "Time to wait for queued and in-flight deployment events on shutdown.")
+
 	err = newCommand.CobraCommand().Execute()
 	if err != nil {
 		return microerror.Mask(err)
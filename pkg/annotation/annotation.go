@@ -0,0 +1,26 @@
+// Package annotation defines the annotations app-checker puts on the App CRs
+// it manages.
+package annotation
+
+const (
+	// Creator is the login of the GitHub user who created the latest
+	// deployment of the App CR.
+	Creator = "app-checker.giantswarm.io/creator"
+	// DeployedAt is the time app-checker last created or updated the App CR
+	// in RFC 3339 format.
+	DeployedAt = "app-checker.giantswarm.io/deployed-at"
+	// DeploymentID is the ID of the GitHub deployment which last created or
+	// updated the App CR.
+	DeploymentID = "app-checker.giantswarm.io/deployment-id"
+	// InstallationID is the ID of the GitHub App installation which delivered
+	// the latest deployment of the App CR.
+	InstallationID = "app-checker.giantswarm.io/installation-id"
+	// Ref is the git ref the App CR is deployed from. It is only set on App
+	// CRs of a single ref, i.e. not on unique ones.
+	Ref = "app-checker.giantswarm.io/ref"
+	// Repository is the full name of the GitHub repository the App CR is
+	// deployed from, e.g. `giantswarm/app-checker`.
+	Repository = "app-checker.giantswarm.io/repository"
+	// SHA is the commit SHA of the latest deployment of the App CR.
+	SHA = "app-checker.giantswarm.io/sha"
+)
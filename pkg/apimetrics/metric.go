@@ -0,0 +1,55 @@
+package apimetrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	PrometheusNamespace = "app_checker"
+)
+
+var (
+	githubRequestHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: PrometheusNamespace,
+			Subsystem: "github",
+			Name:      "request_duration_seconds",
+			Help:      "Time taken by requests against the GitHub API.",
+		},
+		[]string{"method", "code"},
+	)
+
+	githubErrorCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: PrometheusNamespace,
+			Subsystem: "github",
+			Name:      "errors_total",
+			Help:      "Number of requests against the GitHub API which failed or got answered with an error status.",
+		},
+		[]string{"method", "code"},
+	)
+
+	githubRateLimitGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: PrometheusNamespace,
+			Subsystem: "github",
+			Name:      "rate_limit_remaining",
+			Help:      "Number of requests remaining in the current GitHub API rate limit window.",
+		},
+	)
+
+	kubernetesErrorCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: PrometheusNamespace,
+			Subsystem: "kubernetes",
+			Name:      "errors_total",
+			Help:      "Number of requests against the Kubernetes API which failed or got answered with an unexpected error status.",
+		},
+		[]string{"method", "code"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(githubRequestHistogram)
+	prometheus.MustRegister(githubErrorCounter)
+	prometheus.MustRegister(githubRateLimitGauge)
+	prometheus.MustRegister(kubernetesErrorCounter)
+}
@@ -0,0 +1,115 @@
+// Package apimetrics instruments HTTP clients of the APIs app-checker talks
+// to.
+package apimetrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// codeError is the code label of requests which did not get any response.
+	codeError = "error"
+
+	rateLimitRemainingHeader = "X-RateLimit-Remaining"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// NewGithubTransport instruments the latency, errors and remaining rate limit
+// of requests against the GitHub API. A nil next uses
+// http.DefaultTransport.
+func NewGithubTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		start := time.Now()
+
+		res, err := next.RoundTrip(r)
+
+		code := toCode(res, err)
+		githubRequestHistogram.WithLabelValues(r.Method, code).Observe(time.Since(start).Seconds())
+		if isError(res, err) {
+			githubErrorCounter.WithLabelValues(r.Method, code).Inc()
+		}
+
+		if res != nil {
+			remaining, err := strconv.ParseFloat(res.Header.Get(rateLimitRemainingHeader), 64)
+			if err == nil {
+				githubRateLimitGauge.Set(remaining)
+			}
+		}
+
+		return res, err
+	})
+}
+
+// NewKubernetesTransport counts failed requests against the Kubernetes API.
+// It is meant to be used as wrapper of the REST config transport.
+func NewKubernetesTransport(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		res, err := next.RoundTrip(r)
+
+		if isError(res, err) && !isExpectedKubernetesError(res) {
+			kubernetesErrorCounter.WithLabelValues(r.Method, toCode(res, err)).Inc()
+		}
+
+		return res, err
+	})
+}
+
+// isExpectedKubernetesError returns whether the given response is a NotFound
+// or AlreadyExists error. app-checker gets resources before creating them and
+// creates resources which may exist already, so these are not failures. The
+// body of conflicts is restored after looking at their reason.
+func isExpectedKubernetesError(res *http.Response) bool {
+	if res == nil {
+		return false
+	}
+
+	switch res.StatusCode {
+	case http.StatusNotFound:
+		return true
+	case http.StatusConflict:
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return false
+		}
+
+		var status metav1.Status
+		err = json.Unmarshal(body, &status)
+		if err != nil {
+			return false
+		}
+
+		return status.Reason == metav1.StatusReasonAlreadyExists
+	default:
+		return false
+	}
+}
+
+func isError(res *http.Response, err error) bool {
+	return err != nil || res.StatusCode >= http.StatusBadRequest
+}
+
+func toCode(res *http.Response, err error) string {
+	if err != nil {
+		return codeError
+	}
+
+	return strconv.Itoa(res.StatusCode)
+}
@@ -0,0 +1,120 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	kindAppDeployment = "AppDeployment"
+)
+
+func NewAppDeploymentTypeMeta() metav1.TypeMeta {
+	return metav1.TypeMeta{
+		APIVersion: SchemeGroupVersion.String(),
+		Kind:       kindAppDeployment,
+	}
+}
+
+// NewAppDeploymentCR returns an AppDeployment Custom Resource.
+func NewAppDeploymentCR() *AppDeployment {
+	return &AppDeployment{
+		TypeMeta: NewAppDeploymentTypeMeta(),
+	}
+}
+
+// +kubebuilder:printcolumn:name="Repository",type=string,JSONPath=`.spec.repository.name`
+// +kubebuilder:printcolumn:name="Ref",type=string,JSONPath=`.spec.ref`
+// +kubebuilder:printcolumn:name="App",type=string,JSONPath=`.spec.app.name`
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.spec.app.version`
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// AppDeployment records a GitHub deployment processed by app-checker and the
+// deployment statuses reported for it.
+type AppDeployment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              AppDeploymentSpec   `json:"spec"`
+	Status            AppDeploymentStatus `json:"status,omitempty"`
+}
+
+type AppDeploymentSpec struct {
+	// App references the App CR the deployment is deployed to.
+	App AppDeploymentSpecApp `json:"app"`
+	// DeploymentID is the ID of the GitHub deployment.
+	// e.g. 315067829
+	DeploymentID int64 `json:"deploymentID"`
+	// Environment is the GitHub deployment environment.
+	// e.g. ginger
+	Environment string `json:"environment"`
+	// InstallationID is the ID of the GitHub App installation which delivered
+	// the deployment. It is empty when authenticating with an OAuth token.
+	InstallationID int64 `json:"installationID,omitempty"`
+	// Ref is the git ref the deployment was created for.
+	// e.g. master
+	Ref string `json:"ref"`
+	// Repository is the GitHub repository the deployment belongs to.
+	Repository AppDeploymentSpecRepository `json:"repository"`
+	// SHA is the commit SHA the deployment was created for.
+	SHA string `json:"sha"`
+}
+
+type AppDeploymentSpecApp struct {
+	// Catalog is the name of the catalog the app is installed from.
+	// e.g. control-plane-catalog
+	Catalog string `json:"catalog"`
+	// Cluster is the ID of the workload cluster the app is deployed to. It is
+	// empty for the management cluster.
+	Cluster string `json:"cluster,omitempty"`
+	// Name is the name of the App CR.
+	// e.g. app-operator-master
+	Name string `json:"name"`
+	// Namespace is the namespace of the App CR. It is the cluster ID for
+	// apps deployed to workload clusters.
+	// e.g. giantswarm
+	Namespace string `json:"namespace"`
+	// Version is the version of the app chart.
+	// e.g. 1.0.0
+	Version string `json:"version"`
+}
+
+type AppDeploymentSpecRepository struct {
+	// Name is the name of the repository.
+	// e.g. app-operator
+	Name string `json:"name"`
+	// Owner is the login of the repository owner.
+	// e.g. giantswarm
+	Owner string `json:"owner"`
+}
+
+type AppDeploymentStatus struct {
+	// Description is the description of the latest reported status.
+	Description string `json:"description,omitempty"`
+	// FinishedAt is when a final status was reported.
+	// +nullable
+	FinishedAt *metav1.Time `json:"finishedAt,omitempty"`
+	// State is the latest reported GitHub deployment status state.
+	// e.g. success
+	State string `json:"state,omitempty"`
+	// Transitions are the reported statuses, oldest first.
+	Transitions []AppDeploymentStatusTransition `json:"transitions,omitempty"`
+}
+
+type AppDeploymentStatusTransition struct {
+	// Description is the description of the reported status.
+	Description string `json:"description,omitempty"`
+	// State is the reported GitHub deployment status state.
+	State string `json:"state"`
+	// Time is when the status was reported.
+	Time metav1.Time `json:"time"`
+}
+
+// +kubebuilder:object:root=true
+
+type AppDeploymentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AppDeployment `json:"items"`
+}
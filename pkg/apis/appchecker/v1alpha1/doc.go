@@ -0,0 +1,6 @@
+// +kubebuilder:object:generate=true
+
+// Package v1alpha1 contains the custom resources app-checker records its work
+// in.
+// +groupName=appchecker.giantswarm.io
+package v1alpha1
@@ -0,0 +1,34 @@
+// Package bearer authenticates HTTP requests carrying a bearer token.
+package bearer
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	prefix = "Bearer "
+)
+
+// Authorize returns an unauthorized error unless the given request carries
+// the given token in its Authorization header. An empty token rejects every
+// request.
+func Authorize(r *http.Request, token string) error {
+	if token == "" {
+		return microerror.Maskf(unauthorizedError, "bearer token authentication is disabled")
+	}
+
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, prefix) {
+		return microerror.Maskf(unauthorizedError, "bearer token is missing")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(header, prefix)), []byte(token)) != 1 {
+		return microerror.Maskf(unauthorizedError, "bearer token is wrong")
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+package bearer
+
+import "github.com/giantswarm/microerror"
+
+var unauthorizedError = &microerror.Error{
+	Kind: "unauthorizedError",
+}
+
+// IsUnauthorized asserts unauthorizedError.
+func IsUnauthorized(err error) bool {
+	return microerror.Cause(err) == unauthorizedError
+}
@@ -0,0 +1,18 @@
+// Package deploymentstate defines the GitHub deployment status states
+// app-checker reports.
+package deploymentstate
+
+const (
+	Error    = "error"
+	Failure  = "failure"
+	Inactive = "inactive"
+	Pending  = "pending"
+	Success  = "success"
+)
+
+// IsFinal returns whether the given GitHub deployment status state ends a
+// deployment. Removals and dry runs end in Inactive, as does a deployment
+// superseded before it got processed.
+func IsFinal(state string) bool {
+	return state == Success || state == Failure || state == Error || state == Inactive
+}
@@ -0,0 +1,21 @@
+package githubapp
+
+import "github.com/giantswarm/microerror"
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var missingInstallationError = &microerror.Error{
+	Kind: "missingInstallationError",
+}
+
+// IsMissingInstallation asserts missingInstallationError.
+func IsMissingInstallation(err error) bool {
+	return microerror.Cause(err) == missingInstallationError
+}
@@ -0,0 +1,157 @@
+// Package githubapp authenticates GitHub API requests as an installation of a
+// GitHub App. Installation tokens are minted on demand, cached and refreshed
+// shortly before they expire.
+package githubapp
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/google/go-github/v32/github"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/giantswarm/app-checker/pkg/apimetrics"
+)
+
+// tokenRefreshMargin is the remaining lifetime below which a cached
+// installation token is replaced.
+const tokenRefreshMargin = 5 * time.Minute
+
+type installationIDKey struct{}
+
+// WithInstallationID returns a context whose GitHub API requests are
+// authenticated for the given installation instead of the default one.
+func WithInstallationID(ctx context.Context, id int64) context.Context {
+	return context.WithValue(ctx, installationIDKey{}, id)
+}
+
+// InstallationID returns the installation ID carried by ctx, or zero if it
+// carries none.
+func InstallationID(ctx context.Context) int64 {
+	id, _ := ctx.Value(installationIDKey{}).(int64)
+	return id
+}
+
+type Config struct {
+	// Base is the transport requests are sent with after being
+	// authenticated. It defaults to http.DefaultTransport.
+	Base http.RoundTripper
+
+	AppID int64
+	// InstallationID is used for requests whose context does not carry an
+	// installation ID. When zero every request has to carry one.
+	InstallationID int64
+	// PrivateKey is the PEM encoded private key of the GitHub App.
+	PrivateKey []byte
+}
+
+// Transport is an http.RoundTripper authenticating requests with installation
+// tokens.
+type Transport struct {
+	appClient *github.Client
+	base      http.RoundTripper
+
+	installationID int64
+
+	group  singleflight.Group
+	mutex  sync.Mutex
+	tokens map[int64]*github.InstallationToken
+}
+
+func New(config Config) (*Transport, error) {
+	if config.Base == nil {
+		config.Base = http.DefaultTransport
+	}
+
+	if config.AppID == 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.AppID must not be empty", config)
+	}
+	if len(config.PrivateKey) == 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.PrivateKey must not be empty", config)
+	}
+
+	privateKey, err := parsePrivateKey(config.PrivateKey)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var appClient *github.Client
+	{
+		// Token requests are not sent through the instrumented client, so
+		// they are instrumented here.
+		jt := &jwtTransport{
+			appID:      config.AppID,
+			base:       apimetrics.NewGithubTransport(config.Base),
+			privateKey: privateKey,
+		}
+
+		appClient = github.NewClient(&http.Client{Transport: jt})
+	}
+
+	t := &Transport{
+		appClient: appClient,
+		base:      config.Base,
+
+		installationID: config.InstallationID,
+
+		tokens: map[int64]*github.InstallationToken{},
+	}
+
+	return t, nil
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := t.installationID
+	if v, ok := req.Context().Value(installationIDKey{}).(int64); ok && v != 0 {
+		id = v
+	}
+	if id == 0 {
+		return nil, microerror.Maskf(missingInstallationError, "request to %#q carries no GitHub App installation ID", req.URL.Path)
+	}
+
+	token, err := t.token(req.Context(), id)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", "token "+token)
+
+	return t.base.RoundTrip(r)
+}
+
+// token returns a valid token of the given installation. Tokens are minted
+// without holding the lock, so requests of other installations are not
+// blocked, and concurrent requests of the same installation share a single
+// token request.
+func (t *Transport) token(ctx context.Context, id int64) (string, error) {
+	t.mutex.Lock()
+	cached, ok := t.tokens[id]
+	t.mutex.Unlock()
+
+	if ok && time.Until(cached.GetExpiresAt()) > tokenRefreshMargin {
+		return cached.GetToken(), nil
+	}
+
+	v, err, _ := t.group.Do(strconv.FormatInt(id, 10), func() (interface{}, error) {
+		token, _, err := t.appClient.Apps.CreateInstallationToken(ctx, id, nil)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		t.mutex.Lock()
+		t.tokens[id] = token
+		t.mutex.Unlock()
+
+		return token, nil
+	})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return v.(*github.InstallationToken).GetToken(), nil
+}
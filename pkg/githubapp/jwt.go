@@ -0,0 +1,99 @@
+package githubapp
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/giantswarm/microerror"
+)
+
+const (
+	// jwtExpiry stays below the ten minutes GitHub accepts at most.
+	jwtExpiry = 9 * time.Minute
+	// jwtClockDrift backdates the issue time to tolerate clock drift between
+	// app-checker and GitHub.
+	jwtClockDrift = 60 * time.Second
+)
+
+// jwtTransport authenticates requests as the GitHub App itself, which is
+// required to mint installation tokens.
+type jwtTransport struct {
+	appID      int64
+	base       http.RoundTripper
+	privateKey *rsa.PrivateKey
+}
+
+func (t *jwtTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.sign(time.Now())
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", "Bearer "+token)
+
+	return t.base.RoundTrip(r)
+}
+
+func (t *jwtTransport) sign(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+	})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	claims, err := json.Marshal(map[string]interface{}{
+		"exp": now.Add(jwtExpiry).Unix(),
+		"iat": now.Add(-jwtClockDrift).Unix(),
+		"iss": strconv.FormatInt(t.appID, 10),
+	})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, t.privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func parsePrivateKey(b []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, microerror.Maskf(invalidConfigError, "private key is not PEM encoded")
+	}
+
+	// GitHub issues PKCS #1 keys, but converted PKCS #8 keys work as well.
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "parsing private key: %s", err)
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, microerror.Maskf(invalidConfigError, "private key must be an RSA key, got %T", parsed)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,20 @@
+// Package label defines the labels app-checker puts on the resources it
+// manages.
+package label
+
+import "github.com/giantswarm/app-checker/pkg/project"
+
+const (
+	// ManagedBy marks the resources app-checker manages. Its value is
+	// ManagedByValue.
+	ManagedBy = "app.kubernetes.io/managed-by"
+	// Repository is the name of the GitHub repository a resource belongs to.
+	// It is only set when the name is a valid label value.
+	Repository = "app-checker.giantswarm.io/repository"
+)
+
+// ManagedByValue returns the value of the ManagedBy label of the resources
+// app-checker manages.
+func ManagedByValue() string {
+	return project.Name()
+}
@@ -0,0 +1,12 @@
+package pattern
+
+import "github.com/giantswarm/microerror"
+
+var invalidPatternError = &microerror.Error{
+	Kind: "invalidPatternError",
+}
+
+// IsInvalidPattern asserts invalidPatternError.
+func IsInvalidPattern(err error) bool {
+	return microerror.Cause(err) == invalidPatternError
+}
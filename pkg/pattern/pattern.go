@@ -0,0 +1,100 @@
+// Package pattern matches names like repositories or refs against glob
+// patterns or regular expressions. In glob patterns a `*` matches any sequence
+// of characters, including `/`, and a `?` matches any single character.
+// Patterns enclosed in slashes, e.g. `/^.*-app-collection$/`, are regular
+// expressions which are not anchored implicitly.
+package pattern
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+type Pattern struct {
+	raw    string
+	regexp *regexp.Regexp
+}
+
+// Compile parses the given glob pattern or regular expression.
+func Compile(raw string) (*Pattern, error) {
+	if raw == "" {
+		return nil, microerror.Maskf(invalidPatternError, "pattern must not be empty")
+	}
+
+	if len(raw) > 2 && strings.HasPrefix(raw, "/") && strings.HasSuffix(raw, "/") {
+		re, err := regexp.Compile(raw[1 : len(raw)-1])
+		if err != nil {
+			return nil, microerror.Maskf(invalidPatternError, "%#q: %s", raw, err)
+		}
+
+		p := &Pattern{
+			raw:    raw,
+			regexp: re,
+		}
+
+		return p, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range raw {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, microerror.Maskf(invalidPatternError, "%#q: %s", raw, err)
+	}
+
+	p := &Pattern{
+		raw:    raw,
+		regexp: re,
+	}
+
+	return p, nil
+}
+
+// Match returns whether the given name matches the pattern.
+func (p *Pattern) Match(name string) bool {
+	return p.regexp.MatchString(name)
+}
+
+func (p *Pattern) String() string {
+	return p.raw
+}
+
+// CompileAll parses all given patterns.
+func CompileAll(raws []string) ([]*Pattern, error) {
+	var patterns []*Pattern
+	for _, raw := range raws {
+		p, err := Compile(raw)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		patterns = append(patterns, p)
+	}
+
+	return patterns, nil
+}
+
+// MatchAny returns the first of the given patterns matching name, if any.
+func MatchAny(patterns []*Pattern, name string) (*Pattern, bool) {
+	for _, p := range patterns {
+		if p.Match(name) {
+			return p, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,120 @@
+package pattern
+
+import (
+	"testing"
+)
+
+func Test_Pattern_Match(t *testing.T) {
+	testCases := []struct {
+		name    string
+		pattern string
+		input   string
+		match   bool
+	}{
+		{
+			name:    "case 0: literal glob matches equal name",
+			pattern: "app-operator",
+			input:   "app-operator",
+			match:   true,
+		},
+		{
+			name:    "case 1: literal glob is anchored",
+			pattern: "app-operator",
+			input:   "app-operator-test",
+			match:   false,
+		},
+		{
+			name:    "case 2: star matches any sequence",
+			pattern: "*-operator",
+			input:   "app-operator",
+			match:   true,
+		},
+		{
+			name:    "case 3: star matches slashes",
+			pattern: "release-*",
+			input:   "release-v1/fix",
+			match:   true,
+		},
+		{
+			name:    "case 4: question mark matches a single character",
+			pattern: "v?",
+			input:   "v1",
+			match:   true,
+		},
+		{
+			name:    "case 5: question mark does not match two characters",
+			pattern: "v?",
+			input:   "v10",
+			match:   false,
+		},
+		{
+			name:    "case 6: regexp meta characters in globs are literal",
+			pattern: "app.operator",
+			input:   "app-operator",
+			match:   false,
+		},
+		{
+			name:    "case 7: regexp is not anchored implicitly",
+			pattern: "/operator/",
+			input:   "app-operator-test",
+			match:   true,
+		},
+		{
+			name:    "case 8: anchored regexp",
+			pattern: "/^.*-app-collection$/",
+			input:   "aws-app-collection",
+			match:   true,
+		},
+		{
+			name:    "case 9: anchored regexp does not match",
+			pattern: "/^.*-app-collection$/",
+			input:   "aws-app-collection-test",
+			match:   false,
+		},
+		{
+			name:    "case 10: single slash is a glob",
+			pattern: "/",
+			input:   "/",
+			match:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Compile(tc.pattern)
+			if err != nil {
+				t.Fatalf("expected nil, got %#v", err)
+			}
+
+			match := p.Match(tc.input)
+			if match != tc.match {
+				t.Fatalf("expected %t, got %t", tc.match, match)
+			}
+		})
+	}
+}
+
+func Test_Compile_Invalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		pattern string
+	}{
+		{
+			name:    "case 0: empty pattern",
+			pattern: "",
+		},
+		{
+			name:    "case 1: invalid regexp",
+			pattern: "/[a-/",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Compile(tc.pattern)
+			if !IsInvalidPattern(err) {
+				t.Fatalf("expected invalidPatternError, got %#v", err)
+			}
+		})
+	}
+}
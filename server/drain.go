@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+)
+
+const (
+	drainPath = "/drain"
+)
+
+// serveDrain serves the drain endpoint on the loopback drain address. microkit
+// exits about three seconds after SIGTERM without shutting down our server, so
+// the preStop hook of the pod calls the drain endpoint before Kubernetes sends
+// SIGTERM. The request returns once queued and in-flight deployments are
+// processed or the worker shutdown timeout expired. Binding to loopback keeps
+// the endpoint out of reach of anything outside the pod.
+func (s *server) serveDrain() {
+	mux := http.NewServeMux()
+	mux.HandleFunc(drainPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		s.logger.Log("level", "debug", "message", "draining before shutdown")
+
+		s.Shutdown()
+
+		fmt.Fprintln(w, "drained")
+	})
+
+	err := http.ListenAndServe(s.drainAddress, mux)
+	if err != nil {
+		s.logger.Log("level", "error", "message", fmt.Sprintf("failed to serve drain endpoint on %#q", s.drainAddress), "stack", fmt.Sprintf("%#v", err))
+	}
+}
+
+// isLoopback returns whether the given listen address binds to a loopback
+// interface.
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
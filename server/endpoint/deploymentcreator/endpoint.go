@@ -0,0 +1,211 @@
+// Package deploymentcreator deploys apps through the admin API, e.g. while
+// GitHub webhooks are down.
+package deploymentcreator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+
+	"github.com/giantswarm/app-checker/pkg/bearer"
+	"github.com/giantswarm/app-checker/service/deployer"
+	"github.com/giantswarm/app-checker/service/worker"
+)
+
+const (
+	// Method is the HTTP method this endpoint is register for.
+	Method = "POST"
+	// Name identifies the endpoint. It is aligned to the package path.
+	Name = "deploy"
+	// Path is the HTTP request path this endpoint is registered for.
+	Path = "/deploy"
+)
+
+type Config struct {
+	Deployer *deployer.Deployer
+	Logger   micrologger.Logger
+	Worker   *worker.Pool
+
+	// Token is the bearer token admin requests must carry. An empty token
+	// disables the endpoint.
+	Token string
+}
+
+type Endpoint struct {
+	deployer *deployer.Deployer
+	logger   micrologger.Logger
+	worker   *worker.Pool
+
+	token string
+}
+
+// Request is the request body. All other fields are passed on as deployment
+// payload.
+type Request struct {
+	// CreateDeployment creates a GitHub deployment the deployment statuses
+	// are reported to.
+	CreateDeployment bool  `json:"createDeployment"`
+	InstallationID   int64 `json:"installationID"`
+	// Repository is the full name of the GitHub repository, e.g.
+	// `giantswarm/app-operator`.
+	Repository string `json:"repository"`
+	Ref        string `json:"ref"`
+	SHA        string `json:"sha"`
+}
+
+// Response is returned once the deployment is queued.
+type Response struct {
+	ID      int64  `json:"id"`
+	Message string `json:"message"`
+}
+
+func New(config Config) (*Endpoint, error) {
+	if config.Deployer == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Deployer must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Worker == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Worker must not be empty", config)
+	}
+
+	e := &Endpoint{
+		deployer: config.Deployer,
+		logger:   config.Logger,
+		worker:   config.Worker,
+
+		token: config.Token,
+	}
+
+	return e, nil
+}
+
+func (e Endpoint) Decoder() kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		err := bearer.Authorize(r, e.token)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		var request Request
+		err = json.Unmarshal(body, &request)
+		if err != nil {
+			return nil, microerror.Maskf(invalidRequestError, "request body must be a JSON object")
+		}
+
+		owner, repository := splitRepository(request.Repository)
+		if owner == "" || repository == "" {
+			return nil, microerror.Maskf(invalidRequestError, "field `repository` must be the full repository name, e.g. `giantswarm/app-operator`")
+		}
+		if request.Ref == "" {
+			return nil, microerror.Maskf(invalidRequestError, "field `ref` must not be empty")
+		}
+
+		// The remaining fields form the deployment payload.
+		var payload map[string]interface{}
+		err = json.Unmarshal(body, &payload)
+		if err != nil {
+			return nil, microerror.Maskf(invalidRequestError, "request body must be a JSON object")
+		}
+		for _, k := range []string{"createDeployment", "installationID", "repository", "ref", "sha"} {
+			delete(payload, k)
+		}
+
+		rawPayload, err := json.Marshal(payload)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		m := deployer.ManualDeployment{
+			CreateDeployment: request.CreateDeployment,
+			InstallationID:   request.InstallationID,
+			Owner:            owner,
+			Payload:          rawPayload,
+			Ref:              request.Ref,
+			Repository:       repository,
+			SHA:              request.SHA,
+		}
+
+		return m, nil
+	}
+}
+
+func (e Endpoint) Encoder() kithttp.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusAccepted)
+
+		return json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (e Endpoint) Endpoint() kitendpoint.Endpoint {
+	return func(ctx context.Context, r interface{}) (interface{}, error) {
+		m := r.(deployer.ManualDeployment)
+
+		event, err := e.deployer.ManualEvent(ctx, m)
+		if deployer.IsNotFound(err) {
+			return nil, microerror.Maskf(notFoundError, "%s", microerror.Pretty(err, false))
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		err = e.worker.Enqueue(ctx, event)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		e.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("queued manual deployment %d of repository %#q", event.Deployment.GetID(), m.Repository))
+
+		message := fmt.Sprintf("manual deployment %d queued", event.Deployment.GetID())
+		if m.CreateDeployment {
+			message = fmt.Sprintf("GitHub deployment %d created and queued", event.Deployment.GetID())
+		}
+
+		response := Response{
+			ID:      event.Deployment.GetID(),
+			Message: message,
+		}
+
+		return response, nil
+	}
+}
+
+func (e Endpoint) Method() string {
+	return Method
+}
+
+func (e Endpoint) Middlewares() []kitendpoint.Middleware {
+	return []kitendpoint.Middleware{}
+}
+
+func (e Endpoint) Name() string {
+	return Name
+}
+
+func (e Endpoint) Path() string {
+	return Path
+}
+
+func splitRepository(fullName string) (string, string) {
+	i := strings.Index(fullName, "/")
+	if i < 0 {
+		return "", ""
+	}
+
+	return fullName[:i], fullName[i+1:]
+}
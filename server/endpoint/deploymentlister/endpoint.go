@@ -0,0 +1,178 @@
+// Package deploymentlister serves the deployment history of the admin API,
+// optionally filtered by repository, app and status.
+package deploymentlister
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+
+	"github.com/giantswarm/app-checker/pkg/bearer"
+	"github.com/giantswarm/app-checker/service/history"
+)
+
+const (
+	// Method is the HTTP method this endpoint is register for.
+	Method = "GET"
+	// Name identifies the endpoint. It is aligned to the package path.
+	Name = "deployments/lister"
+	// Path is the HTTP request path this endpoint is registered for.
+	Path = "/deployments"
+)
+
+type Config struct {
+	History *history.Recorder
+	Logger  micrologger.Logger
+
+	// Token is the bearer token admin requests must carry. An empty token
+	// disables the endpoint.
+	Token string
+}
+
+type Endpoint struct {
+	history *history.Recorder
+	logger  micrologger.Logger
+
+	token string
+}
+
+// Request filters the listed deployments. Empty fields match every
+// deployment.
+type Request struct {
+	// App is the name of the App CR.
+	App string
+	// Repository is the name or full name of the GitHub repository.
+	Repository string
+	// Status is the latest GitHub deployment status state.
+	Status string
+}
+
+// Response is a deployment of the deployment history.
+type Response struct {
+	App         string     `json:"app"`
+	Cluster     string     `json:"cluster,omitempty"`
+	Description string     `json:"description,omitempty"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+	ID          int64      `json:"id"`
+	Namespace   string     `json:"namespace"`
+	Ref         string     `json:"ref"`
+	Repository  string     `json:"repository"`
+	SHA         string     `json:"sha"`
+	StartedAt   time.Time  `json:"startedAt"`
+	State       string     `json:"state,omitempty"`
+	Version     string     `json:"version"`
+}
+
+func New(config Config) (*Endpoint, error) {
+	if config.History == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.History must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	e := &Endpoint{
+		history: config.History,
+		logger:  config.Logger,
+
+		token: config.Token,
+	}
+
+	return e, nil
+}
+
+func (e Endpoint) Decoder() kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		err := bearer.Authorize(r, e.token)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		q := r.URL.Query()
+
+		request := Request{
+			App:        q.Get("app"),
+			Repository: q.Get("repository"),
+			Status:     q.Get("status"),
+		}
+
+		return request, nil
+	}
+}
+
+func (e Endpoint) Encoder() kithttp.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		return json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (e Endpoint) Endpoint() kitendpoint.Endpoint {
+	return func(ctx context.Context, r interface{}) (interface{}, error) {
+		request := r.(Request)
+
+		deployments, err := e.history.List(ctx)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		responses := []Response{}
+		for _, d := range deployments {
+			repository := d.Spec.Repository.Owner + "/" + d.Spec.Repository.Name
+
+			if request.App != "" && request.App != d.Spec.App.Name {
+				continue
+			}
+			if request.Repository != "" && request.Repository != d.Spec.Repository.Name && request.Repository != repository {
+				continue
+			}
+			if request.Status != "" && request.Status != d.Status.State {
+				continue
+			}
+
+			response := Response{
+				App:         d.Spec.App.Name,
+				Cluster:     d.Spec.App.Cluster,
+				Description: d.Status.Description,
+				ID:          d.Spec.DeploymentID,
+				Namespace:   d.Spec.App.Namespace,
+				Ref:         d.Spec.Ref,
+				Repository:  repository,
+				SHA:         d.Spec.SHA,
+				StartedAt:   d.GetCreationTimestamp().Time,
+				State:       d.Status.State,
+				Version:     d.Spec.App.Version,
+			}
+			if d.Status.FinishedAt != nil {
+				response.FinishedAt = &d.Status.FinishedAt.Time
+			}
+
+			responses = append(responses, response)
+		}
+
+		return responses, nil
+	}
+}
+
+func (e Endpoint) Method() string {
+	return Method
+}
+
+func (e Endpoint) Middlewares() []kitendpoint.Middleware {
+	return []kitendpoint.Middleware{}
+}
+
+func (e Endpoint) Name() string {
+	return Name
+}
+
+func (e Endpoint) Path() string {
+	return Path
+}
@@ -0,0 +1,195 @@
+// Package deploymentretrier re-processes a deployment of the deployment
+// history through the admin API.
+package deploymentretrier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+
+	"github.com/giantswarm/app-checker/pkg/bearer"
+	"github.com/giantswarm/app-checker/service/deployer"
+	"github.com/giantswarm/app-checker/service/history"
+	"github.com/giantswarm/app-checker/service/worker"
+)
+
+const (
+	// Method is the HTTP method this endpoint is register for.
+	Method = "POST"
+	// Name identifies the endpoint. It is aligned to the package path.
+	Name = "deployments/retrier"
+	// Path is the HTTP request path this endpoint is registered for.
+	Path = "/deployments/{id}/retry"
+)
+
+type Config struct {
+	Deployer *deployer.Deployer
+	History  *history.Recorder
+	Logger   micrologger.Logger
+	Worker   *worker.Pool
+
+	// Token is the bearer token admin requests must carry. An empty token
+	// disables the endpoint.
+	Token string
+}
+
+type Endpoint struct {
+	deployer *deployer.Deployer
+	history  *history.Recorder
+	logger   micrologger.Logger
+	worker   *worker.Pool
+
+	token string
+}
+
+// Request identifies a deployment of the deployment history.
+type Request struct {
+	ID int64
+	// Repository is the name or full name of the GitHub repository.
+	Repository string
+}
+
+// Response is returned once the deployment is queued again.
+type Response struct {
+	ID      int64  `json:"id"`
+	Message string `json:"message"`
+}
+
+func New(config Config) (*Endpoint, error) {
+	if config.Deployer == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Deployer must not be empty", config)
+	}
+	if config.History == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.History must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Worker == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Worker must not be empty", config)
+	}
+
+	e := &Endpoint{
+		deployer: config.Deployer,
+		history:  config.History,
+		logger:   config.Logger,
+		worker:   config.Worker,
+
+		token: config.Token,
+	}
+
+	return e, nil
+}
+
+func (e Endpoint) Decoder() kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		err := bearer.Authorize(r, e.token)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			return nil, microerror.Maskf(invalidRequestError, "deployment ID must be a number")
+		}
+
+		repository := r.URL.Query().Get("repository")
+		if repository == "" {
+			return nil, microerror.Maskf(invalidRequestError, "repository query parameter must not be empty")
+		}
+
+		request := Request{
+			ID:         id,
+			Repository: repository[strings.LastIndex(repository, "/")+1:],
+		}
+
+		return request, nil
+	}
+}
+
+func (e Endpoint) Encoder() kithttp.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusAccepted)
+
+		return json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (e Endpoint) Endpoint() kitendpoint.Endpoint {
+	return func(ctx context.Context, r interface{}) (interface{}, error) {
+		request := r.(Request)
+		id := request.ID
+
+		if id < 0 {
+			return nil, microerror.Maskf(invalidRequestError, "manual deployment %d has no GitHub deployment to retry, deploy it again instead", id)
+		}
+
+		d, err := e.history.Find(ctx, request.Repository, id)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		if d == nil {
+			return nil, microerror.Maskf(notFoundError, "deployment %d of repository %#q is not in the deployment history", id, request.Repository)
+		}
+
+		event, err := e.deployer.RetryEvent(ctx, d.Spec.Repository.Owner, d.Spec.Repository.Name, id, d.Spec.InstallationID)
+		if deployer.IsNotFound(err) {
+			return nil, microerror.Maskf(notFoundError, "deployment %d does not exist on GitHub anymore", id)
+		} else if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		// A newer deployment of the same App CR supersedes the retried one, so
+		// it would only be reported inactive again. The newer deployment has
+		// to be retried instead.
+		supersededBy, err := e.deployer.SupersededBy(event)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		if supersededBy != 0 {
+			return nil, microerror.Maskf(conflictError, "deployment %d is superseded by deployment %d of the same app, retry deployment %d instead", id, supersededBy, supersededBy)
+		}
+
+		// Retrying through the worker queue gets the deployment serialized with
+		// other deployments of its App CR.
+		err = e.worker.Enqueue(ctx, event)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		e.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("queued retry of deployment %d of repository %#q", id, d.Spec.Repository.Name))
+
+		response := Response{
+			ID:      id,
+			Message: fmt.Sprintf("deployment %d queued", id),
+		}
+
+		return response, nil
+	}
+}
+
+func (e Endpoint) Method() string {
+	return Method
+}
+
+func (e Endpoint) Middlewares() []kitendpoint.Middleware {
+	return []kitendpoint.Middleware{}
+}
+
+func (e Endpoint) Name() string {
+	return Name
+}
+
+func (e Endpoint) Path() string {
+	return Path
+}
@@ -0,0 +1,195 @@
+// Package deploymentsearcher serves a single deployment of the admin API
+// including the full timeline of its status transitions.
+package deploymentsearcher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+
+	"github.com/giantswarm/app-checker/pkg/bearer"
+	"github.com/giantswarm/app-checker/service/history"
+)
+
+const (
+	// Method is the HTTP method this endpoint is register for.
+	Method = "GET"
+	// Name identifies the endpoint. It is aligned to the package path.
+	Name = "deployments/searcher"
+	// Path is the HTTP request path this endpoint is registered for.
+	Path = "/deployments/{id}"
+)
+
+type Config struct {
+	History *history.Recorder
+	Logger  micrologger.Logger
+
+	// Token is the bearer token admin requests must carry. An empty token
+	// disables the endpoint.
+	Token string
+}
+
+type Endpoint struct {
+	history *history.Recorder
+	logger  micrologger.Logger
+
+	token string
+}
+
+// Request identifies a deployment of the deployment history.
+type Request struct {
+	ID int64
+	// Repository is the name or full name of the GitHub repository.
+	Repository string
+}
+
+// Response is a deployment of the deployment history.
+type Response struct {
+	App            string       `json:"app"`
+	Catalog        string       `json:"catalog"`
+	Cluster        string       `json:"cluster,omitempty"`
+	Description    string       `json:"description,omitempty"`
+	Environment    string       `json:"environment"`
+	FinishedAt     *time.Time   `json:"finishedAt,omitempty"`
+	ID             int64        `json:"id"`
+	InstallationID int64        `json:"installationID,omitempty"`
+	Namespace      string       `json:"namespace"`
+	Ref            string       `json:"ref"`
+	Repository     string       `json:"repository"`
+	SHA            string       `json:"sha"`
+	StartedAt      time.Time    `json:"startedAt"`
+	State          string       `json:"state,omitempty"`
+	Transitions    []Transition `json:"transitions"`
+	Version        string       `json:"version"`
+}
+
+// Transition is a GitHub deployment status reported for the deployment.
+type Transition struct {
+	Description string    `json:"description,omitempty"`
+	State       string    `json:"state"`
+	Time        time.Time `json:"time"`
+}
+
+func New(config Config) (*Endpoint, error) {
+	if config.History == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.History must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	e := &Endpoint{
+		history: config.History,
+		logger:  config.Logger,
+
+		token: config.Token,
+	}
+
+	return e, nil
+}
+
+func (e Endpoint) Decoder() kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		err := bearer.Authorize(r, e.token)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			return nil, microerror.Maskf(invalidRequestError, "deployment ID must be a number")
+		}
+
+		repository := r.URL.Query().Get("repository")
+		if repository == "" {
+			return nil, microerror.Maskf(invalidRequestError, "repository query parameter must not be empty")
+		}
+
+		request := Request{
+			ID:         id,
+			Repository: repository[strings.LastIndex(repository, "/")+1:],
+		}
+
+		return request, nil
+	}
+}
+
+func (e Endpoint) Encoder() kithttp.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		return json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (e Endpoint) Endpoint() kitendpoint.Endpoint {
+	return func(ctx context.Context, r interface{}) (interface{}, error) {
+		request := r.(Request)
+		id := request.ID
+
+		d, err := e.history.Find(ctx, request.Repository, id)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		if d == nil {
+			return nil, microerror.Maskf(notFoundError, "deployment %d of repository %#q is not in the deployment history", id, request.Repository)
+		}
+
+		response := Response{
+			App:            d.Spec.App.Name,
+			Catalog:        d.Spec.App.Catalog,
+			Cluster:        d.Spec.App.Cluster,
+			Description:    d.Status.Description,
+			Environment:    d.Spec.Environment,
+			ID:             d.Spec.DeploymentID,
+			InstallationID: d.Spec.InstallationID,
+			Namespace:      d.Spec.App.Namespace,
+			Ref:            d.Spec.Ref,
+			Repository:     d.Spec.Repository.Owner + "/" + d.Spec.Repository.Name,
+			SHA:            d.Spec.SHA,
+			StartedAt:      d.GetCreationTimestamp().Time,
+			State:          d.Status.State,
+			Transitions:    []Transition{},
+			Version:        d.Spec.App.Version,
+		}
+		if d.Status.FinishedAt != nil {
+			response.FinishedAt = &d.Status.FinishedAt.Time
+		}
+		for _, t := range d.Status.Transitions {
+			transition := Transition{
+				Description: t.Description,
+				State:       t.State,
+				Time:        t.Time.Time,
+			}
+
+			response.Transitions = append(response.Transitions, transition)
+		}
+
+		return response, nil
+	}
+}
+
+func (e Endpoint) Method() string {
+	return Method
+}
+
+func (e Endpoint) Middlewares() []kitendpoint.Middleware {
+	return []kitendpoint.Middleware{}
+}
+
+func (e Endpoint) Name() string {
+	return Name
+}
+
+func (e Endpoint) Path() string {
+	return Path
+}
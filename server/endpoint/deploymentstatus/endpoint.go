@@ -0,0 +1,231 @@
+// Package deploymentstatus serves the status page GitHub deployment statuses
+// link to as their log URL. The page is public, so it only shows the latest
+// status of a deployment unless the request carries the admin bearer token.
+// Deployments no longer tracked in memory, e.g. after a restart, are served
+// from their AppDeployment CR.
+package deploymentstatus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+
+	"github.com/giantswarm/app-checker/pkg/apis/appchecker/v1alpha1"
+	"github.com/giantswarm/app-checker/pkg/bearer"
+	"github.com/giantswarm/app-checker/service/history"
+	"github.com/giantswarm/app-checker/service/tracker"
+)
+
+const (
+	// Method is the HTTP method this endpoint is register for.
+	Method = "GET"
+	// Name identifies the endpoint. It is aligned to the package path.
+	Name = "status/deployments"
+	// Path is the HTTP request path this endpoint is registered for.
+	Path = "/status/deployments/{id}"
+)
+
+type Config struct {
+	History *history.Recorder
+	Logger  micrologger.Logger
+	Tracker *tracker.Tracker
+
+	// Token is the bearer token of the admin API. Requests carrying it get
+	// all details of a deployment. An empty token never shows them.
+	Token string
+}
+
+type Endpoint struct {
+	history *history.Recorder
+	logger  micrologger.Logger
+	tracker *tracker.Tracker
+
+	token string
+}
+
+// Request identifies the deployment to show.
+type Request struct {
+	// Authorized is whether the request carries the admin bearer token.
+	Authorized bool
+	ID         int64
+	// Repository is the name of the GitHub repository of the deployment. Log
+	// URLs carry it so the AppDeployment CR can be looked up by name.
+	Repository string
+}
+
+// Response is the latest status of a deployment shown to unauthorized
+// requests. It leaves out the repository, ref, App CR and dry run diff, as
+// log URLs are guessable.
+type Response struct {
+	Description string    `json:"description,omitempty"`
+	ID          int64     `json:"id"`
+	State       string    `json:"state,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func New(config Config) (*Endpoint, error) {
+	if config.History == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.History must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Tracker == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Tracker must not be empty", config)
+	}
+
+	e := &Endpoint{
+		history: config.History,
+		logger:  config.Logger,
+		tracker: config.Tracker,
+
+		token: config.Token,
+	}
+
+	return e, nil
+}
+
+func (e Endpoint) Decoder() kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			return nil, microerror.Maskf(invalidRequestError, "deployment ID must be a number")
+		}
+
+		request := Request{
+			Authorized: bearer.Authorize(r, e.token) == nil,
+			ID:         id,
+			Repository: r.URL.Query().Get("repository"),
+		}
+
+		return request, nil
+	}
+}
+
+func (e Endpoint) Encoder() kithttp.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		return json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (e Endpoint) Endpoint() kitendpoint.Endpoint {
+	return func(ctx context.Context, r interface{}) (interface{}, error) {
+		request := r.(Request)
+		id := request.ID
+
+		deployment, ok := e.tracker.Get(id)
+		if !ok {
+			cr, err := e.find(ctx, request.Repository, id)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			if cr == nil {
+				return nil, microerror.Maskf(notFoundError, "deployment %d is not known", id)
+			}
+
+			deployment = fromHistory(cr)
+		}
+
+		if request.Authorized {
+			return deployment, nil
+		}
+
+		response := Response{
+			ID:        deployment.ID,
+			UpdatedAt: deployment.UpdatedAt,
+		}
+		if n := len(deployment.Statuses); n > 0 {
+			response.Description = deployment.Statuses[n-1].Description
+			response.State = deployment.Statuses[n-1].State
+		}
+
+		return response, nil
+	}
+}
+
+// find returns the AppDeployment CR of the given deployment, or nil when
+// there is none. Without repository, e.g. for log URLs published before they
+// carried it, the deployment history is searched for the ID.
+func (e Endpoint) find(ctx context.Context, repository string, id int64) (*v1alpha1.AppDeployment, error) {
+	if repository != "" {
+		cr, err := e.history.Find(ctx, repository, id)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		return cr, nil
+	}
+
+	deployments, err := e.history.List(ctx)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for i := range deployments {
+		if deployments[i].Spec.DeploymentID == id {
+			return &deployments[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// fromHistory returns the tracked deployment recorded in the given
+// AppDeployment CR. Dry run diffs are not recorded and left out.
+func fromHistory(cr *v1alpha1.AppDeployment) tracker.Deployment {
+	d := tracker.Deployment{
+		ID:             cr.Spec.DeploymentID,
+		Owner:          cr.Spec.Repository.Owner,
+		Repository:     cr.Spec.Repository.Name,
+		Ref:            cr.Spec.Ref,
+		SHA:            cr.Spec.SHA,
+		InstallationID: cr.Spec.InstallationID,
+		App: tracker.App{
+			Catalog:   cr.Spec.App.Catalog,
+			Cluster:   cr.Spec.App.Cluster,
+			Name:      cr.Spec.App.Name,
+			Namespace: cr.Spec.App.Namespace,
+			Version:   cr.Spec.App.Version,
+		},
+		CreatedAt: cr.GetCreationTimestamp().Time,
+		UpdatedAt: cr.GetCreationTimestamp().Time,
+		Statuses:  []tracker.Status{},
+	}
+
+	for _, t := range cr.Status.Transitions {
+		d.Statuses = append(d.Statuses, tracker.Status{
+			Description: t.Description,
+			State:       t.State,
+			Time:        t.Time.Time,
+		})
+		d.UpdatedAt = t.Time.Time
+	}
+
+	return d
+}
+
+func (e Endpoint) Method() string {
+	return Method
+}
+
+func (e Endpoint) Middlewares() []kitendpoint.Middleware {
+	return []kitendpoint.Middleware{}
+}
+
+func (e Endpoint) Name() string {
+	return Name
+}
+
+func (e Endpoint) Path() string {
+	return Path
+}
@@ -1,36 +1,45 @@
 package endpoint
 
 import (
-	"github.com/giantswarm/k8sclient/v5/pkg/k8sclient"
 	"github.com/giantswarm/microendpoint/endpoint/healthz"
 	"github.com/giantswarm/microendpoint/endpoint/version"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 
+	"github.com/giantswarm/app-checker/server/endpoint/deploymentcreator"
+	"github.com/giantswarm/app-checker/server/endpoint/deploymentlister"
+	"github.com/giantswarm/app-checker/server/endpoint/deploymentretrier"
+	"github.com/giantswarm/app-checker/server/endpoint/deploymentsearcher"
+	"github.com/giantswarm/app-checker/server/endpoint/deploymentstatus"
 	"github.com/giantswarm/app-checker/server/endpoint/githubwebhook"
+	"github.com/giantswarm/app-checker/server/endpoint/webhookstatus"
 	"github.com/giantswarm/app-checker/service"
 )
 
 type Config struct {
-	K8sClient k8sclient.Interface
-	Logger    micrologger.Logger
-	Service   *service.Service
-
-	Environment      string
-	GithubToken      string
-	WebhookSecretKey []byte
+	Logger  micrologger.Logger
+	Service *service.Service
+
+	// AdminToken is the bearer token of the admin API. An empty token
+	// disables the admin API.
+	AdminToken        string
+	Environment       string
+	WebhookSecretKeys [][]byte
 }
 
 type Endpoint struct {
-	GithubWebhook *githubwebhook.Endpoint
-	Healthz       *healthz.Endpoint
-	Version       *version.Endpoint
+	DeploymentCreator  *deploymentcreator.Endpoint
+	DeploymentLister   *deploymentlister.Endpoint
+	DeploymentRetrier  *deploymentretrier.Endpoint
+	DeploymentSearcher *deploymentsearcher.Endpoint
+	DeploymentStatus   *deploymentstatus.Endpoint
+	GithubWebhook      *githubwebhook.Endpoint
+	Healthz            *healthz.Endpoint
+	Version            *version.Endpoint
+	WebhookStatus      *webhookstatus.Endpoint
 }
 
 func New(config Config) (*Endpoint, error) {
-	if config.K8sClient == nil {
-		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
-	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
@@ -41,24 +50,103 @@ func New(config Config) (*Endpoint, error) {
 	if config.Environment == "" {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Environment must not be empty", config)
 	}
-	if config.GithubToken == "" {
-		return nil, microerror.Maskf(invalidConfigError, "%T.GithubToken must not be empty", config)
-	}
-	if config.WebhookSecretKey == nil {
-		return nil, microerror.Maskf(invalidConfigError, "%T.WebhookSecretKey must not be empty", config)
+	if len(config.WebhookSecretKeys) == 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.WebhookSecretKeys must not be empty", config)
 	}
 
 	var err error
 
+	var deploymentCreatorEndpoint *deploymentcreator.Endpoint
+	{
+		c := deploymentcreator.Config{
+			Deployer: config.Service.Deployer,
+			Logger:   config.Logger,
+			Worker:   config.Service.Worker,
+
+			Token: config.AdminToken,
+		}
+
+		deploymentCreatorEndpoint, err = deploymentcreator.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var deploymentListerEndpoint *deploymentlister.Endpoint
+	{
+		c := deploymentlister.Config{
+			History: config.Service.History,
+			Logger:  config.Logger,
+
+			Token: config.AdminToken,
+		}
+
+		deploymentListerEndpoint, err = deploymentlister.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var deploymentRetrierEndpoint *deploymentretrier.Endpoint
+	{
+		c := deploymentretrier.Config{
+			Deployer: config.Service.Deployer,
+			History:  config.Service.History,
+			Logger:   config.Logger,
+			Worker:   config.Service.Worker,
+
+			Token: config.AdminToken,
+		}
+
+		deploymentRetrierEndpoint, err = deploymentretrier.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var deploymentSearcherEndpoint *deploymentsearcher.Endpoint
+	{
+		c := deploymentsearcher.Config{
+			History: config.Service.History,
+			Logger:  config.Logger,
+
+			Token: config.AdminToken,
+		}
+
+		deploymentSearcherEndpoint, err = deploymentsearcher.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var deploymentStatusEndpoint *deploymentstatus.Endpoint
+	{
+		c := deploymentstatus.Config{
+			History: config.Service.History,
+			Logger:  config.Logger,
+			Tracker: config.Service.Tracker,
+
+			Token: config.AdminToken,
+		}
+
+		deploymentStatusEndpoint, err = deploymentstatus.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var githubWebhookEndpoint *githubwebhook.Endpoint
 	{
 		c := githubwebhook.Config{
-			K8sClient: config.K8sClient,
-			Logger:    config.Logger,
-
-			Env:              config.Environment,
-			GithubToken:      config.GithubToken,
-			WebhookSecretKey: config.WebhookSecretKey,
+			Dedupe:     config.Service.Dedupe,
+			Deliveries: config.Service.Deliveries,
+			Deployer:   config.Service.Deployer,
+			Filter:     config.Service.Filter,
+			Logger:     config.Logger,
+			Worker:     config.Service.Worker,
+
+			Env:               config.Environment,
+			WebhookSecretKeys: config.WebhookSecretKeys,
 		}
 
 		githubWebhookEndpoint, err = githubwebhook.New(c)
@@ -92,10 +180,31 @@ func New(config Config) (*Endpoint, error) {
 		}
 	}
 
+	var webhookStatusEndpoint *webhookstatus.Endpoint
+	{
+		c := webhookstatus.Config{
+			Deliveries: config.Service.Deliveries,
+			Logger:     config.Logger,
+
+			Env: config.Environment,
+		}
+
+		webhookStatusEndpoint, err = webhookstatus.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	e := &Endpoint{
-		GithubWebhook: githubWebhookEndpoint,
-		Healthz:       healthzEndpoint,
-		Version:       versionEndpoint,
+		DeploymentCreator:  deploymentCreatorEndpoint,
+		DeploymentLister:   deploymentListerEndpoint,
+		DeploymentRetrier:  deploymentRetrierEndpoint,
+		DeploymentSearcher: deploymentSearcherEndpoint,
+		DeploymentStatus:   deploymentStatusEndpoint,
+		GithubWebhook:      githubWebhookEndpoint,
+		Healthz:            healthzEndpoint,
+		Version:            versionEndpoint,
+		WebhookStatus:      webhookStatusEndpoint,
 	}
 
 	return e, nil
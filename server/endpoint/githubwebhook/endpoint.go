@@ -4,26 +4,23 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
-	"reflect"
-	"strconv"
+	"net/url"
 	"time"
 
-	"github.com/Masterminds/semver/v3"
-	"github.com/giantswarm/apiextensions/v3/pkg/apis/application/v1alpha1"
-	"github.com/giantswarm/app/v4/pkg/app"
-	"github.com/giantswarm/app/v4/pkg/key"
-	"github.com/giantswarm/k8sclient/v5/pkg/k8sclient"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	kitendpoint "github.com/go-kit/kit/endpoint"
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/google/go-github/v32/github"
-	"golang.org/x/oauth2"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/fields"
-	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/giantswarm/app-checker/pkg/deploymentstate"
+	"github.com/giantswarm/app-checker/service/dedupe"
+	"github.com/giantswarm/app-checker/service/deliveries"
+	"github.com/giantswarm/app-checker/service/deployer"
+	"github.com/giantswarm/app-checker/service/filter"
+	"github.com/giantswarm/app-checker/service/worker"
 )
 
 const (
@@ -34,74 +31,91 @@ const (
 	// Path is the HTTP request path this endpoint is registered for.
 	Path = "/"
 
-	releases = "releases"
-)
+	deliveryHeader        = "X-GitHub-Delivery"
+	signatureHeader       = "X-Hub-Signature"
+	signatureSHA256Header = "X-Hub-Signature-256"
 
-var (
-	draughtsmanRepositories = map[string]bool{
-		"draughtsman":                true,
-		"aws-app-collection":         true,
-		"azure-app-collection":       true,
-		"kvm-app-collection":         true,
-		"conformance-app-collection": true,
-	}
+	// unknownEventType is the event type rejected deliveries of unhandled
+	// event types are recorded as. The event type header is not authenticated,
+	// so it must not create arbitrary entries.
+	unknownEventType = "unknown"
 )
 
-type Config struct {
-	K8sClient k8sclient.Interface
-	Logger    micrologger.Logger
+// handledEvents are the webhook event types app-checker acts on.
+var handledEvents = []string{
+	"delete",
+	"deployment",
+	"ping",
+}
 
-	Env              string
-	GithubToken      string
-	WebhookSecretKey []byte
+type Config struct {
+	Dedupe     *dedupe.Cache
+	Deliveries *deliveries.Recorder
+	Deployer   *deployer.Deployer
+	Filter     *filter.Filter
+	Logger     micrologger.Logger
+	Worker     *worker.Pool
+
+	Env string
+	// WebhookSecretKeys are the secrets accepted for payload signatures. More
+	// than one secret is only configured while rotating them.
+	WebhookSecretKeys [][]byte
 }
 
 type Endpoint struct {
-	k8sClient k8sclient.Interface
-	logger    micrologger.Logger
-
-	env              string
-	githubClient     *github.Client
-	webhookSecretKey []byte
-	waitDuration     time.Duration
+	dedupe     *dedupe.Cache
+	deliveries *deliveries.Recorder
+	deployer   *deployer.Deployer
+	filter     *filter.Filter
+	logger     micrologger.Logger
+	worker     *worker.Pool
+
+	env               string
+	webhookSecretKeys [][]byte
 }
 
 func New(config Config) (*Endpoint, error) {
-	if config.K8sClient == nil {
-		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	if config.Dedupe == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Dedupe must not be empty", config)
+	}
+	if config.Deliveries == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Deliveries must not be empty", config)
+	}
+	if config.Deployer == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Deployer must not be empty", config)
+	}
+	if config.Filter == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Filter must not be empty", config)
 	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
+	if config.Worker == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Worker must not be empty", config)
+	}
 
 	if config.Env == "" {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Env must not be empty", config)
 	}
-	if config.GithubToken == "" {
-		return nil, microerror.Maskf(invalidConfigError, "%T.GithubToken must not be empty", config)
+	if len(config.WebhookSecretKeys) == 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.WebhookSecretKeys must not be empty", config)
 	}
-	if config.WebhookSecretKey == nil {
-		return nil, microerror.Maskf(invalidConfigError, "%T.WebhookSecretKey must not be empty", config)
-	}
-
-	var githubClient *github.Client
-	{
-		ctx := context.Background()
-		ts := oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: config.GithubToken},
-		)
-		tc := oauth2.NewClient(ctx, ts)
-
-		githubClient = github.NewClient(tc)
+	for i, k := range config.WebhookSecretKeys {
+		if len(k) == 0 {
+			return nil, microerror.Maskf(invalidConfigError, "%T.WebhookSecretKeys[%d] must not be empty", config, i)
+		}
 	}
 
 	e := &Endpoint{
-		k8sClient: config.K8sClient,
-		logger:    config.Logger,
+		dedupe:     config.Dedupe,
+		deliveries: config.Deliveries,
+		deployer:   config.Deployer,
+		filter:     config.Filter,
+		logger:     config.Logger,
+		worker:     config.Worker,
 
-		env:          config.Env,
-		githubClient: githubClient,
-		waitDuration: 1 * time.Minute,
+		env:               config.Env,
+		webhookSecretKeys: config.WebhookSecretKeys,
 	}
 
 	return e, nil
@@ -109,17 +123,42 @@ func New(config Config) (*Endpoint, error) {
 
 func (e Endpoint) Decoder() kithttp.DecodeRequestFunc {
 	return func(ctx context.Context, r *http.Request) (interface{}, error) {
-		payload, err := github.ValidatePayload(r, e.webhookSecretKey)
-		if err != nil {
+		eventType := github.WebHookType(r)
+
+		payload, signature, err := e.validatePayload(r)
+		if IsWrongTokenError(err) {
+			rejectedType := eventType
+			if !isHandled(rejectedType) {
+				rejectedType = unknownEventType
+			}
+
+			e.deliveries.Rejected(rejectedType, microerror.Pretty(err, false))
+			return nil, microerror.Mask(err)
+		} else if err != nil {
 			return nil, microerror.Mask(err)
 		}
 
-		event, err := github.ParseWebHook(github.WebHookType(r), payload)
+		event, err := github.ParseWebHook(eventType, payload)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
 
-		return event, nil
+		var repository string
+		if r, ok := event.(interface{ GetRepo() *github.Repository }); ok {
+			repository = r.GetRepo().GetName()
+		}
+
+		eventCounter.WithLabelValues(eventType, repository).Inc()
+		d := &delivery{
+			event:     event,
+			eventType: eventType,
+			id:        r.Header.Get(deliveryHeader),
+			signature: signature,
+		}
+
+		e.deliveries.Received(eventType, d.id)
+
+		return d, nil
 	}
 }
 
@@ -127,255 +166,260 @@ func (e Endpoint) Encoder() kithttp.EncodeResponseFunc {
 	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 		w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
+		if r, ok := response.(*Response); ok && r.Accepted {
+			w.WriteHeader(http.StatusAccepted)
+		}
+
 		return json.NewEncoder(w).Encode(response)
 	}
 }
 
 func (e Endpoint) Endpoint() kitendpoint.Endpoint {
 	return func(ctx context.Context, r interface{}) (interface{}, error) {
-		switch event := r.(type) {
+		d := r.(*delivery)
+
+		switch event := d.event.(type) {
 		case *github.DeploymentEvent:
-			if *event.Deployment.Environment == e.env {
-				if draughtsmanRepositories[*event.Repo.Name] {
-					e.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("no need to deploy for draughtsman project %#q", *event.Repo.Name))
-					return nil, nil
-				}
-
-				err := e.processDeploymentEvent(ctx, event)
-				if err != nil {
-					return nil, microerror.Mask(err)
-				}
+			if event.Deployment.GetEnvironment() != e.env {
+				return e.ignore(ctx, ignoredEnvironment, fmt.Sprintf("deployment environment %#q does not match %#q", event.Deployment.GetEnvironment(), e.env)), nil
 			}
-		default:
-			// no-op
-		}
-		return nil, nil
-	}
-}
-
-func (e *Endpoint) processDeploymentEvent(ctx context.Context, event *github.DeploymentEvent) error {
-	payload, err := parsePayload(event.Deployment.Payload)
-	if err != nil {
-		return microerror.Mask(err)
-	}
 
-	var appCRName string
-	{
-		var prefixName string
-		{
-			if *event.Repo.Name == releases {
-				prefixName = payload.Chart
-			} else {
-				prefixName = *event.Repo.Name
+			// Tasks of jobs app-checker queues itself cannot be requested
+			// through GitHub deployments.
+			if task := event.Deployment.GetTask(); task == deployer.TaskManual || task == deployer.TaskRemoveBranch {
+				return e.ignore(ctx, ignoredTask, fmt.Sprintf("deployment task %#q is reserved", task)), nil
 			}
-		}
 
-		if payload.Unique {
-			appCRName = fmt.Sprintf("%s-%s", prefixName, "unique")
-		} else {
-			appCRName = fmt.Sprintf("%s-%s", prefixName, *event.Deployment.Ref)
-		}
-	}
+			reason, ignored := e.filter.Ignore(event.Repo.GetOwner().GetLogin(), event.Repo.GetName())
+			if ignored {
+				return e.ignore(ctx, ignoredRepository, reason), nil
+			}
 
-	var catalog string
-	{
-		v, err := semver.NewVersion(payload.AppVersion)
-		if err != nil {
-			return microerror.Mask(err)
-		}
+			if entry, ok := e.dedupe.Reserve(ctx, d.id, pending(d, event.Repo.GetName())); ok {
+				return e.redelivered(ctx, entry), nil
+			}
 
-		if v.Prerelease() == "" {
-			catalog = "control-plane-catalog"
-		} else {
-			catalog = "control-plane-test-catalog"
-		}
+			_, err := deployer.ParseTaskPayload(event.Deployment.GetTask(), event.Deployment.Payload)
+			if err != nil {
+				e.dedupe.Release(d.id)
+				return nil, microerror.Mask(err)
+			}
 
-		if *event.Repo.Name == releases {
-			if *event.Deployment.Ref == "master" {
-				catalog = releases
-			} else {
-				catalog = fmt.Sprintf("%s-test", releases)
+			err = e.worker.Enqueue(ctx, event)
+			if err != nil {
+				e.dedupe.Release(d.id)
+				return nil, microerror.Mask(err)
 			}
-		}
-	}
 
-	appConfig := app.Config{
-		AppName:             *event.Repo.Name,
-		AppNamespace:        payload.Namespace,
-		AppCatalog:          catalog,
-		AppVersion:          payload.AppVersion,
-		DisableForceUpgrade: true,
-		Name:                appCRName,
-	}
+			e.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("queued deployment %d of repository %#q", event.Deployment.GetID(), event.Repo.GetName()))
 
-	if *event.Repo.Name == releases {
-		appConfig.AppName = payload.Chart
-	}
+			response := &Response{
+				Accepted: true,
+				Message:  fmt.Sprintf("deployment %d queued", event.Deployment.GetID()),
+			}
 
-	desiredAppCR := app.NewCR(appConfig)
+			e.dedupe.Put(ctx, d.id, dedupe.Entry{
+				DeploymentIDs: []int64{event.Deployment.GetID()},
+				EventType:     d.eventType,
+				Message:       response.Message,
+				ReceivedAt:    time.Now(),
+				Repository:    event.Repo.GetName(),
+			})
 
-	var lastResourceVersion uint64
-	var created bool
+			return response, nil
 
-	// Find matching app CR.
-	currentApp, err := e.k8sClient.G8sClient().ApplicationV1alpha1().Apps(payload.Namespace).Get(ctx, appCRName, metav1.GetOptions{})
-	if apierrors.IsNotFound(err) {
-		created = true
-		newApp, err := e.k8sClient.G8sClient().ApplicationV1alpha1().Apps(payload.Namespace).Create(ctx, desiredAppCR, metav1.CreateOptions{})
-		if err != nil {
-			return microerror.Mask(err)
-		}
+		case *github.DeleteEvent:
+			reason, ignored := e.filter.Ignore(event.Repo.GetOwner().GetLogin(), event.Repo.GetName())
+			if ignored {
+				return e.ignore(ctx, ignoredRepository, reason), nil
+			}
 
-		lastResourceVersion, err = getResourceVersion(newApp.GetResourceVersion())
-		if err != nil {
-			return microerror.Mask(err)
-		}
-	} else if err != nil {
-		return microerror.Mask(err)
-	} else {
-		lastResourceVersion, err = getResourceVersion(currentApp.GetResourceVersion())
-		if err != nil {
-			return microerror.Mask(err)
-		}
-	}
+			if entry, ok := e.dedupe.Reserve(ctx, d.id, pending(d, event.Repo.GetName())); ok {
+				return e.redelivered(ctx, entry), nil
+			}
 
-	if !created {
-		// if app is equal to the desired spec, no op.
-		if equals(currentApp, desiredAppCR) {
-			e.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deployed already app %#q with version %#q", appCRName, payload.AppVersion))
+			removal := e.deployer.BranchRemovalEvent(event)
+			if removal == nil {
+				e.dedupe.Release(d.id)
+				return e.ignore(ctx, ignoredRef, fmt.Sprintf("deleted %s %#q is no branch", event.GetRefType(), event.GetRef())), nil
+			}
 
-			err = e.reportStatus(ctx, event, currentApp.Status.Release.Status, currentApp.Status.Release.Reason)
+			err := e.worker.Enqueue(ctx, removal)
 			if err != nil {
-				return microerror.Mask(err)
+				e.dedupe.Release(d.id)
+				return nil, microerror.Mask(err)
 			}
 
-			return nil
-		}
+			e.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("queued removal of apps of branch %#q of repository %#q", event.GetRef(), event.Repo.GetName()))
 
-		desiredAppCR.ObjectMeta.ResourceVersion = currentApp.GetResourceVersion()
+			response := &Response{
+				Accepted: true,
+				Message:  fmt.Sprintf("removal of apps of branch %#q queued", event.GetRef()),
+			}
 
-		// if app is not equal to the desired spec, update current app.
-		updateAppCR, err := e.k8sClient.G8sClient().ApplicationV1alpha1().Apps(payload.Namespace).Update(ctx, desiredAppCR, metav1.UpdateOptions{})
-		if err != nil {
-			return microerror.Mask(err)
-		}
+			e.dedupe.Put(ctx, d.id, dedupe.Entry{
+				DeploymentIDs: []int64{removal.Deployment.GetID()},
+				EventType:     d.eventType,
+				Message:       response.Message,
+				ReceivedAt:    time.Now(),
+				Repository:    event.Repo.GetName(),
+			})
 
-		lastResourceVersion, err = getResourceVersion(updateAppCR.GetResourceVersion())
-		if err != nil {
-			return microerror.Mask(err)
+			return response, nil
+
+		case *github.PingEvent:
+			e.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("answering ping of webhook %d", event.GetHookID()))
+
+			return e.pong(event, d.signature), nil
+
+		default:
+			return e.ignore(ctx, ignoredEventType, fmt.Sprintf("event type %T is not handled", event)), nil
 		}
 	}
+}
 
-	e.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deploying app %#q with version %#q", appCRName, payload.AppVersion))
+// ignore answers events app-checker does not act on. The category is the
+// low cardinality reason exposed as metric label.
+func (e Endpoint) ignore(ctx context.Context, category, reason string) *Response {
+	ignoredEventCounter.WithLabelValues(category).Inc()
 
-	timeoutSeconds := int64(30)
-	lo := metav1.ListOptions{
-		FieldSelector:  fields.OneTermEqualSelector("metadata.name", appCRName).String(),
-		TimeoutSeconds: &timeoutSeconds,
-	}
+	e.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("ignoring event: %s", reason))
 
-	res, err := e.k8sClient.G8sClient().ApplicationV1alpha1().Apps(payload.Namespace).Watch(ctx, lo)
-	if err != nil {
-		return microerror.Mask(err)
+	response := &Response{
+		Accepted: false,
+		Message:  reason,
 	}
 
-	// Waiting for status update.
-	// meanwhile, creating deployment status event.
-	err = e.reportStatus(ctx, event, "in_progress", "")
-	if err != nil {
-		return microerror.Mask(err)
+	return response
+}
+
+// pending returns the dedupe entry reserving the given delivery while it is
+// being handled.
+func pending(d *delivery, repository string) dedupe.Entry {
+	entry := dedupe.Entry{
+		EventType:  d.eventType,
+		ReceivedAt: time.Now(),
+		Repository: repository,
 	}
 
-	for r := range res.ResultChan() {
-		switch r.Type {
-		case watch.Error:
-			e.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("got error event: %#q", r.Object))
-			return nil
+	return entry
+}
 
-		case watch.Modified:
-			cr, err := key.ToApp(r.Object)
-			if err != nil {
-				return microerror.Mask(err)
-			}
+// redelivered answers a redelivery of an already handled delivery with the
+// recorded result instead of handling it again. Redelivered deployments are
+// answered with their latest status.
+func (e Endpoint) redelivered(ctx context.Context, entry dedupe.Entry) *Response {
+	if entry.Message == "" {
+		return e.ignore(ctx, ignoredRedelivery, fmt.Sprintf("redelivery of %s event which is being handled", entry.EventType))
+	}
 
-			resourceVersion, err := getResourceVersion(cr.GetResourceVersion())
-			if err != nil {
-				return microerror.Mask(err)
-			}
+	reason := fmt.Sprintf("redelivery of %s event answered with %#q before", entry.EventType, entry.Message)
 
-			if resourceVersion <= lastResourceVersion {
-				// no-op
-				e.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("no need to reconcile for the older resourceVersion %d", resourceVersion))
-				continue
-			}
+	if entry.EventType == "deployment" && len(entry.DeploymentIDs) == 1 {
+		id := entry.DeploymentIDs[0]
 
-			status := cr.Status.Release.Status
+		state, description, err := e.deployer.LastStatus(ctx, entry.Repository, id)
+		if err != nil {
+			e.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to get status of deployment %d", id), "stack", fmt.Sprintf("%#v", err))
+		} else if deploymentstate.IsFinal(state) && description != "" {
+			reason = fmt.Sprintf("redelivery of deployment %d which finished with status %#q: %s", id, state, description)
+		} else if deploymentstate.IsFinal(state) {
+			reason = fmt.Sprintf("redelivery of deployment %d which finished with status %#q", id, state)
+		} else {
+			reason = fmt.Sprintf("redelivery of deployment %d which is being processed", id)
+		}
+	}
 
-			err = e.reportStatus(ctx, event, status, currentApp.Status.Release.Reason)
-			if err != nil {
-				return microerror.Mask(err)
-			}
+	return e.ignore(ctx, ignoredRedelivery, reason)
+}
 
-			if status == "deployed" || status == "not-installed" || status == "failed" {
-				e.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("app %#q with version %#q deployment status: %#q", appCRName, payload.AppVersion, status))
-				return nil
-			}
+// pong answers a ping event with the configuration of app-checker, so a newly
+// created webhook shows whether it is wired up correctly.
+func (e Endpoint) pong(event *github.PingEvent, signature string) *PingResponse {
+	subscribed := map[string]bool{}
+	for _, t := range event.GetHook().Events {
+		subscribed[t] = true
+	}
+
+	var missing []string
+	for _, t := range handledEvents {
+		if t != "ping" && !subscribed[t] && !subscribed["*"] {
+			missing = append(missing, t)
 		}
 	}
 
-	err = e.reportStatus(ctx, event, "failure", "deployment take longer than 30 seconds. check app-operator logs")
-	if err != nil {
-		return microerror.Mask(err)
+	response := &PingResponse{
+		Environment:   e.env,
+		HandledEvents: handledEvents,
+		MissingEvents: missing,
+		Signature:     signature,
+		Zen:           event.GetZen(),
 	}
 
-	return nil
+	return response
 }
 
-func (e *Endpoint) reportStatus(ctx context.Context, event *github.DeploymentEvent, status, reason string) error {
-	var err error
-	switch status {
-	case "deployed":
-		err = e.updateGithubDeploymentStatus(ctx, event, "success", "")
-		if err != nil {
-			return microerror.Mask(err)
-		}
+// validatePayload reads the webhook payload and verifies its signature
+// against all configured secrets. The SHA-256 signature is preferred over the
+// legacy SHA-1 one. It also returns a description of the valid signature.
+func (e Endpoint) validatePayload(r *http.Request) ([]byte, string, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, "", microerror.Mask(err)
+	}
 
-		return nil
+	header := signatureSHA256Header
+	signature := r.Header.Get(signatureSHA256Header)
+	if signature == "" {
+		header = signatureHeader
+		signature = r.Header.Get(signatureHeader)
+	}
+	if signature == "" {
+		return nil, "", microerror.Maskf(wrongTokenError, "missing payload signature")
+	}
 
-	case "not-installed", "failed":
-		err = e.updateGithubDeploymentStatus(ctx, event, "failure", reason)
-		if err != nil {
-			return microerror.Mask(err)
+	valid := -1
+	for i, k := range e.webhookSecretKeys {
+		if github.ValidateSignature(signature, body, k) == nil {
+			valid = i
+			break
 		}
+	}
+	if valid < 0 {
+		return nil, "", microerror.Maskf(wrongTokenError, "payload signature does not match any webhook secret")
+	}
 
-		return nil
+	// The first secret is the current one, any other one is only accepted
+	// while rotating secrets.
+	description := fmt.Sprintf("valid %s signature", header)
+	if valid > 0 {
+		description = fmt.Sprintf("valid %s signature using the previous webhook secret", header)
+	}
 
-	default:
-		err = e.updateGithubDeploymentStatus(ctx, event, "pending", reason)
+	switch ct := r.Header.Get("Content-Type"); ct {
+	case "application/json":
+		return body, description, nil
+	case "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
 		if err != nil {
-			return microerror.Mask(err)
+			return nil, "", microerror.Mask(err)
 		}
-	}
-
-	return nil
-}
 
-func (e *Endpoint) updateGithubDeploymentStatus(ctx context.Context, event *github.DeploymentEvent, status, reason string) error {
-	if len(reason) >= 140 {
-		reason = reason[0:137] + "..."
-	}
-	request := github.DeploymentStatusRequest{
-		State:       &status,
-		Description: &reason,
-		Environment: &e.env,
+		return []byte(form.Get("payload")), description, nil
+	default:
+		return nil, "", microerror.Maskf(decodeFailedError, "unsupported content type %#q", ct)
 	}
+}
 
-	_, _, err := e.githubClient.Repositories.CreateDeploymentStatus(ctx, *event.Repo.Owner.Login, event.Repo.GetName(), *event.Deployment.ID, &request)
-	if err != nil {
-		return microerror.Mask(err)
+// isHandled returns whether app-checker acts on the given webhook event type.
+func isHandled(eventType string) bool {
+	for _, t := range handledEvents {
+		if t == eventType {
+			return true
+		}
 	}
 
-	return nil
+	return false
 }
 
 func (e Endpoint) Method() string {
@@ -393,45 +437,3 @@ func (e Endpoint) Name() string {
 func (e Endpoint) Path() string {
 	return Path
 }
-
-// equals asseses the equality of ReleaseStates with regards to distinguishing fields.
-func equals(current, desired *v1alpha1.App) bool {
-	if current.Name != desired.Name {
-		return false
-	}
-	if !reflect.DeepEqual(current.Spec, desired.Spec) {
-		return false
-	}
-	if !reflect.DeepEqual(current.Labels, desired.Labels) {
-		return false
-	}
-
-	return true
-}
-
-func getResourceVersion(resourceVersion string) (uint64, error) {
-	r, err := strconv.ParseUint(resourceVersion, 0, 64)
-	if err != nil {
-		return 0, microerror.Mask(err)
-	}
-
-	return r, nil
-}
-
-func parsePayload(rawPayload []byte) (*payload, error) {
-	var e payload
-
-	err := json.Unmarshal(rawPayload, &e)
-	if err != nil {
-		return nil, microerror.Mask(err)
-	}
-
-	if e.AppVersion == "" {
-		return nil, microerror.Maskf(decodeFailedError, "not found field `appVersion` in payload")
-	}
-	if e.Namespace == "" {
-		return nil, microerror.Maskf(decodeFailedError, "not found field `namespace` in payload")
-	}
-
-	return &e, nil
-}
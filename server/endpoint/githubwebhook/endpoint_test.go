@@ -0,0 +1,255 @@
+package githubwebhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/giantswarm/app-checker/service/deliveries"
+)
+
+func Test_Endpoint_validatePayload(t *testing.T) {
+	body := []byte(`{"zen":"Keep it logically awesome."}`)
+
+	testCases := []struct {
+		name        string
+		secrets     []string
+		contentType string
+		body        []byte
+		// headers are the signature headers of the request.
+		headers             map[string]string
+		expectedPayload     []byte
+		expectedDescription string
+		errorMatcher        func(error) bool
+	}{
+		{
+			name:        "case 0: valid SHA-256 signature",
+			secrets:     []string{"current"},
+			contentType: "application/json",
+			body:        body,
+			headers: map[string]string{
+				signatureSHA256Header: sign(sha256.New, "sha256", "current", body),
+			},
+			expectedPayload:     body,
+			expectedDescription: "valid X-Hub-Signature-256 signature",
+		},
+		{
+			name:        "case 1: valid SHA-1 signature",
+			secrets:     []string{"current"},
+			contentType: "application/json",
+			body:        body,
+			headers: map[string]string{
+				signatureHeader: sign(sha1.New, "sha1", "current", body),
+			},
+			expectedPayload:     body,
+			expectedDescription: "valid X-Hub-Signature signature",
+		},
+		{
+			name:        "case 2: SHA-256 signature preferred over SHA-1",
+			secrets:     []string{"current"},
+			contentType: "application/json",
+			body:        body,
+			headers: map[string]string{
+				signatureHeader:       sign(sha1.New, "sha1", "other", body),
+				signatureSHA256Header: sign(sha256.New, "sha256", "current", body),
+			},
+			expectedPayload:     body,
+			expectedDescription: "valid X-Hub-Signature-256 signature",
+		},
+		{
+			name:        "case 3: invalid SHA-256 signature not falling back to SHA-1",
+			secrets:     []string{"current"},
+			contentType: "application/json",
+			body:        body,
+			headers: map[string]string{
+				signatureHeader:       sign(sha1.New, "sha1", "current", body),
+				signatureSHA256Header: sign(sha256.New, "sha256", "other", body),
+			},
+			errorMatcher: IsWrongTokenError,
+		},
+		{
+			name:        "case 4: signature using the previous secret",
+			secrets:     []string{"current", "previous"},
+			contentType: "application/json",
+			body:        body,
+			headers: map[string]string{
+				signatureSHA256Header: sign(sha256.New, "sha256", "previous", body),
+			},
+			expectedPayload:     body,
+			expectedDescription: "valid X-Hub-Signature-256 signature using the previous webhook secret",
+		},
+		{
+			name:        "case 5: signature not matching any secret",
+			secrets:     []string{"current", "previous"},
+			contentType: "application/json",
+			body:        body,
+			headers: map[string]string{
+				signatureSHA256Header: sign(sha256.New, "sha256", "other", body),
+			},
+			errorMatcher: IsWrongTokenError,
+		},
+		{
+			name:         "case 6: missing signature",
+			secrets:      []string{"current"},
+			contentType:  "application/json",
+			body:         body,
+			errorMatcher: IsWrongTokenError,
+		},
+		{
+			name:        "case 7: form encoded payload",
+			secrets:     []string{"current"},
+			contentType: "application/x-www-form-urlencoded",
+			body:        []byte(url.Values{"payload": {string(body)}}.Encode()),
+			headers: map[string]string{
+				signatureSHA256Header: sign(sha256.New, "sha256", "current", []byte(url.Values{"payload": {string(body)}}.Encode())),
+			},
+			expectedPayload:     body,
+			expectedDescription: "valid X-Hub-Signature-256 signature",
+		},
+		{
+			name:        "case 8: unsupported content type",
+			secrets:     []string{"current"},
+			contentType: "text/plain",
+			body:        body,
+			headers: map[string]string{
+				signatureSHA256Header: sign(sha256.New, "sha256", "current", body),
+			},
+			errorMatcher: IsDecodeFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var keys [][]byte
+			for _, s := range tc.secrets {
+				keys = append(keys, []byte(s))
+			}
+
+			e := Endpoint{
+				webhookSecretKeys: keys,
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tc.body))
+			r.Header.Set("Content-Type", tc.contentType)
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+
+			payload, description, err := e.validatePayload(r)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if !bytes.Equal(payload, tc.expectedPayload) {
+				t.Fatalf("payload == %q, want %q", payload, tc.expectedPayload)
+			}
+			if description != tc.expectedDescription {
+				t.Fatalf("description == %#q, want %#q", description, tc.expectedDescription)
+			}
+		})
+	}
+}
+
+func Test_Endpoint_Decoder(t *testing.T) {
+	body := []byte(`{"zen":"Keep it logically awesome."}`)
+
+	testCases := []struct {
+		name      string
+		eventType string
+		signature string
+		// expectedEventType is the event type the delivery is recorded for.
+		expectedEventType string
+		expectedReceived  int64
+		expectedRejected  int64
+		errorMatcher      func(error) bool
+	}{
+		{
+			name:              "case 0: delivery with valid signature received",
+			eventType:         "ping",
+			signature:         sign(sha256.New, "sha256", "current", body),
+			expectedEventType: "ping",
+			expectedReceived:  1,
+		},
+		{
+			// The server answers wrong token errors with 401 Unauthorized.
+			name:              "case 1: delivery with mismatching signature rejected",
+			eventType:         "ping",
+			signature:         sign(sha256.New, "sha256", "other", body),
+			expectedEventType: "ping",
+			expectedRejected:  1,
+			errorMatcher:      IsWrongTokenError,
+		},
+		{
+			name:              "case 2: unhandled delivery with mismatching signature rejected as unknown",
+			eventType:         "push",
+			signature:         sign(sha256.New, "sha256", "other", body),
+			expectedEventType: unknownEventType,
+			expectedRejected:  1,
+			errorMatcher:      IsWrongTokenError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder, err := deliveries.New(deliveries.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			e := Endpoint{
+				deliveries:        recorder,
+				webhookSecretKeys: [][]byte{[]byte("current")},
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+			r.Header.Set("Content-Type", "application/json")
+			r.Header.Set("X-GitHub-Event", tc.eventType)
+			r.Header.Set(signatureSHA256Header, tc.signature)
+
+			_, err = e.Decoder()(context.Background(), r)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			event := recorder.List()[tc.expectedEventType]
+			if event.Received != tc.expectedReceived {
+				t.Fatalf("received == %d, want %d", event.Received, tc.expectedReceived)
+			}
+			if event.Rejected != tc.expectedRejected {
+				t.Fatalf("rejected == %d, want %d", event.Rejected, tc.expectedRejected)
+			}
+		})
+	}
+}
+
+// sign returns the signature header value GitHub sends for the given body.
+func sign(h func() hash.Hash, prefix, secret string, body []byte) string {
+	mac := hmac.New(h, []byte(secret))
+	_, _ = mac.Write(body)
+
+	return prefix + "=" + hex.EncodeToString(mac.Sum(nil))
+}
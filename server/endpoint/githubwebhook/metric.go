@@ -0,0 +1,44 @@
+package githubwebhook
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	PrometheusNamespace = "app_checker"
+	PrometheusSubsystem = "webhook"
+)
+
+const (
+	ignoredEnvironment = "environment"
+	ignoredEventType   = "event_type"
+	ignoredRedelivery  = "redelivery"
+	ignoredRef         = "ref"
+	ignoredRepository  = "repository"
+	ignoredTask        = "task"
+)
+
+var (
+	eventCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: PrometheusNamespace,
+			Subsystem: PrometheusSubsystem,
+			Name:      "events_total",
+			Help:      "Number of received webhook events with valid signature.",
+		},
+		[]string{"event", "repository"},
+	)
+
+	ignoredEventCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: PrometheusNamespace,
+			Subsystem: PrometheusSubsystem,
+			Name:      "ignored_events_total",
+			Help:      "Number of received webhook events which were ignored.",
+		},
+		[]string{"reason"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(eventCounter)
+	prometheus.MustRegister(ignoredEventCounter)
+}
@@ -12,3 +12,13 @@ type Request struct {
 	Status       string  `json:"status"`
 	Version      string  `json:"version"`
 }
+
+// delivery is a webhook delivery with a valid signature.
+type delivery struct {
+	event     interface{}
+	eventType string
+	// id is the GUID of the delivery, which is kept by redeliveries.
+	id string
+	// signature describes the valid signature of the delivery.
+	signature string
+}
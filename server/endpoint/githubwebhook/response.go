@@ -0,0 +1,20 @@
+package githubwebhook
+
+// Response is returned to GitHub for every webhook delivery.
+type Response struct {
+	Accepted bool   `json:"accepted"`
+	Message  string `json:"message"`
+}
+
+// PingResponse answers ping events, which GitHub sends when a webhook is
+// created, with the configuration of app-checker.
+type PingResponse struct {
+	Environment string `json:"environment"`
+	// HandledEvents are the event types app-checker acts on.
+	HandledEvents []string `json:"handledEvents"`
+	// MissingEvents are the handled event types the webhook is not
+	// subscribed to.
+	MissingEvents []string `json:"missingEvents,omitempty"`
+	Signature     string   `json:"signature"`
+	Zen           string   `json:"zen"`
+}
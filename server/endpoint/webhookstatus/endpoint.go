@@ -0,0 +1,109 @@
+// Package webhookstatus serves the webhook deliveries received per event type,
+// so operators can confirm the GitHub webhook is wired up correctly.
+package webhookstatus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	kitendpoint "github.com/go-kit/kit/endpoint"
+	kithttp "github.com/go-kit/kit/transport/http"
+
+	"github.com/giantswarm/app-checker/service/deliveries"
+)
+
+const (
+	// Method is the HTTP method this endpoint is register for.
+	Method = "GET"
+	// Name identifies the endpoint. It is aligned to the package path.
+	Name = "status/webhook"
+	// Path is the HTTP request path this endpoint is registered for.
+	Path = "/status/webhook"
+)
+
+type Config struct {
+	Deliveries *deliveries.Recorder
+	Logger     micrologger.Logger
+
+	Env string
+}
+
+type Endpoint struct {
+	deliveries *deliveries.Recorder
+	logger     micrologger.Logger
+
+	env string
+}
+
+// Response lists the webhook deliveries received since app-checker started,
+// keyed by event type.
+type Response struct {
+	Environment string                      `json:"environment"`
+	Events      map[string]deliveries.Event `json:"events"`
+}
+
+func New(config Config) (*Endpoint, error) {
+	if config.Deliveries == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Deliveries must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.Env == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Env must not be empty", config)
+	}
+
+	e := &Endpoint{
+		deliveries: config.Deliveries,
+		logger:     config.Logger,
+
+		env: config.Env,
+	}
+
+	return e, nil
+}
+
+func (e Endpoint) Decoder() kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		return nil, nil
+	}
+}
+
+func (e Endpoint) Encoder() kithttp.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		return json.NewEncoder(w).Encode(response)
+	}
+}
+
+func (e Endpoint) Endpoint() kitendpoint.Endpoint {
+	return func(ctx context.Context, r interface{}) (interface{}, error) {
+		response := &Response{
+			Environment: e.env,
+			Events:      e.deliveries.List(),
+		}
+
+		return response, nil
+	}
+}
+
+func (e Endpoint) Method() string {
+	return Method
+}
+
+func (e Endpoint) Middlewares() []kitendpoint.Middleware {
+	return []kitendpoint.Middleware{}
+}
+
+func (e Endpoint) Name() string {
+	return Name
+}
+
+func (e Endpoint) Path() string {
+	return Path
+}
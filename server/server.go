@@ -7,23 +7,29 @@ import (
 	"net/http"
 	"sync"
 
-	"github.com/giantswarm/k8sclient/v5/pkg/k8sclient"
 	"github.com/giantswarm/microerror"
 	microserver "github.com/giantswarm/microkit/server"
 	"github.com/giantswarm/micrologger"
 	"github.com/spf13/viper"
 
 	"github.com/giantswarm/app-checker/flag"
+	"github.com/giantswarm/app-checker/pkg/bearer"
 	"github.com/giantswarm/app-checker/pkg/project"
 	"github.com/giantswarm/app-checker/server/endpoint"
+	"github.com/giantswarm/app-checker/server/endpoint/deploymentcreator"
+	"github.com/giantswarm/app-checker/server/endpoint/deploymentretrier"
+	"github.com/giantswarm/app-checker/server/endpoint/deploymentsearcher"
+	"github.com/giantswarm/app-checker/server/endpoint/deploymentstatus"
+	"github.com/giantswarm/app-checker/server/endpoint/githubwebhook"
 	"github.com/giantswarm/app-checker/service"
+	"github.com/giantswarm/app-checker/service/deployer"
+	"github.com/giantswarm/app-checker/service/worker"
 )
 
 type Config struct {
-	Flag      *flag.Flag
-	K8sClient k8sclient.Interface
-	Logger    micrologger.Logger
-	Service   *service.Service
+	Flag    *flag.Flag
+	Logger  micrologger.Logger
+	Service *service.Service
 
 	Viper *viper.Viper
 }
@@ -32,28 +38,51 @@ func New(config Config) (microserver.Server, error) {
 	if config.Flag == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Flag must not be empty", config)
 	}
-	if config.K8sClient == nil {
-		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
-	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
+	if config.Service == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Service must not be empty", config)
+	}
 	if config.Viper == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Viper must not be empty", config)
 	}
 
 	var err error
 
+	var webhookSecretKeys [][]byte
+	{
+		for _, k := range []string{config.Flag.Service.Github.WebhookSecretKey, config.Flag.Service.Github.PreviousWebhookSecretKey} {
+			secret := config.Viper.GetString(k)
+			if secret != "" {
+				webhookSecretKeys = append(webhookSecretKeys, []byte(secret))
+			}
+		}
+	}
+
+	adminToken := config.Viper.GetString(config.Flag.Service.Admin.Token)
+	for _, k := range webhookSecretKeys {
+		if adminToken != "" && adminToken == string(k) {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Flag.Service.Admin.Token must differ from the webhook secret keys", config)
+		}
+	}
+
+	// The drain endpoint is unauthenticated, so it must only be reachable from
+	// within the pod.
+	drainAddress := config.Viper.GetString(config.Flag.Service.Worker.DrainAddress)
+	if drainAddress != "" && !isLoopback(drainAddress) {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Flag.Service.Worker.DrainAddress must be a loopback address", config)
+	}
+
 	var endpointCollection *endpoint.Endpoint
 	{
 		c := endpoint.Config{
-			K8sClient: config.K8sClient,
-			Logger:    config.Logger,
-			Service:   config.Service,
+			Logger:  config.Logger,
+			Service: config.Service,
 
-			Environment:      config.Viper.GetString(config.Flag.Service.Installation.Environment),
-			GithubToken:      config.Viper.GetString(config.Flag.Service.Github.GitHubToken),
-			WebhookSecretKey: []byte(config.Viper.GetString(config.Flag.Service.Github.WebhookSecretKey)),
+			AdminToken:        adminToken,
+			Environment:       config.Viper.GetString(config.Flag.Service.Installation.Environment),
+			WebhookSecretKeys: webhookSecretKeys,
 		}
 
 		endpointCollection, err = endpoint.New(c)
@@ -63,7 +92,10 @@ func New(config Config) (microserver.Server, error) {
 	}
 
 	s := &server{
-		logger: config.Logger,
+		logger:  config.Logger,
+		service: config.Service,
+
+		drainAddress: drainAddress,
 
 		bootOnce: sync.Once{},
 		config: microserver.Config{
@@ -72,9 +104,15 @@ func New(config Config) (microserver.Server, error) {
 			Viper:       config.Viper,
 
 			Endpoints: []microserver.Endpoint{
+				endpointCollection.DeploymentCreator,
+				endpointCollection.DeploymentLister,
+				endpointCollection.DeploymentRetrier,
+				endpointCollection.DeploymentSearcher,
+				endpointCollection.DeploymentStatus,
 				endpointCollection.GithubWebhook,
 				endpointCollection.Healthz,
 				endpointCollection.Version,
+				endpointCollection.WebhookStatus,
 			},
 			ErrorEncoder: encodeError,
 		},
@@ -85,7 +123,10 @@ func New(config Config) (microserver.Server, error) {
 }
 
 type server struct {
-	logger micrologger.Logger
+	logger  micrologger.Logger
+	service *service.Service
+
+	drainAddress string
 
 	bootOnce     sync.Once
 	config       microserver.Config
@@ -94,8 +135,16 @@ type server struct {
 
 func (s *server) Boot() {
 	s.bootOnce.Do(func() {
-		// Here goes your custom boot logic for your server/endpoint if
-		// any.
+		s.service.AppWatcher.Boot()
+		s.service.History.Boot()
+		s.service.Reporter.Boot()
+		s.service.Worker.Boot()
+		if s.service.GC != nil {
+			s.service.GC.Boot()
+		}
+		if s.drainAddress != "" {
+			go s.serveDrain()
+		}
 	})
 }
 
@@ -105,8 +154,15 @@ func (s *server) Config() microserver.Config {
 
 func (s *server) Shutdown() {
 	s.shutdownOnce.Do(func() {
-		// Here goes your custom shutdown logic for your
-		// server/endpoint if any.
+		// Let queued and in-flight deployments finish so their GitHub
+		// deployment statuses are reported.
+		if s.service.GC != nil {
+			s.service.GC.Shutdown()
+		}
+		s.service.Worker.Shutdown()
+		s.service.Reporter.Shutdown()
+		s.service.History.Shutdown()
+		s.service.AppWatcher.Shutdown()
 	})
 }
 
@@ -114,7 +170,29 @@ func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
 	rErr := err.(microserver.ResponseError)
 	uErr := rErr.Underlying()
 
-	rErr.SetCode(microserver.CodeInternalError)
 	rErr.SetMessage(uErr.Error())
-	w.WriteHeader(http.StatusInternalServerError)
+
+	switch {
+	case githubwebhook.IsWrongTokenError(uErr), bearer.IsUnauthorized(uErr):
+		rErr.SetCode(microserver.CodeInvalidCredentials)
+		w.WriteHeader(http.StatusUnauthorized)
+	case deploymentstatus.IsNotFound(uErr), deploymentcreator.IsNotFound(uErr), deploymentretrier.IsNotFound(uErr), deploymentsearcher.IsNotFound(uErr):
+		rErr.SetCode(microserver.CodeResourceNotFound)
+		w.WriteHeader(http.StatusNotFound)
+	case deploymentstatus.IsInvalidRequest(uErr), deploymentcreator.IsInvalidRequest(uErr), deploymentretrier.IsInvalidRequest(uErr), deploymentsearcher.IsInvalidRequest(uErr):
+		rErr.SetCode(microserver.CodeInvalidInput)
+		w.WriteHeader(http.StatusBadRequest)
+	case deploymentretrier.IsConflict(uErr):
+		rErr.SetCode(microserver.CodeFailure)
+		w.WriteHeader(http.StatusConflict)
+	case deployer.IsDecodeFailed(uErr), githubwebhook.IsDecodeFailed(uErr):
+		rErr.SetCode(microserver.CodeInvalidInput)
+		w.WriteHeader(http.StatusBadRequest)
+	case worker.IsQueueFull(uErr), worker.IsShutdown(uErr):
+		rErr.SetCode(microserver.CodeNotYetAvailable)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	default:
+		rErr.SetCode(microserver.CodeInternalError)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
 }
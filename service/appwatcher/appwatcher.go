@@ -0,0 +1,176 @@
+// Package appwatcher tracks App CRs with a single shared informer and
+// delivers their updates and deletion to the deployments waiting for them.
+// The informer re-establishes its watch automatically, so waiting deployments
+// do not miss updates when the API server closes a watch.
+package appwatcher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v3/pkg/apis/application/v1alpha1"
+	"github.com/giantswarm/k8sclient/v5/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+type Config struct {
+	K8sClient k8sclient.Interface
+	Logger    micrologger.Logger
+
+	// ResyncPeriod is the interval in which all cached App CRs are delivered
+	// to subscribers again.
+	ResyncPeriod time.Duration
+}
+
+type Watcher struct {
+	informer cache.SharedIndexInformer
+	logger   micrologger.Logger
+
+	bootOnce      sync.Once
+	mutex         sync.Mutex
+	shutdownOnce  sync.Once
+	stop          chan struct{}
+	subscriptions map[string]map[*Subscription]struct{}
+}
+
+func New(config Config) (*Watcher, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	apps := config.K8sClient.G8sClient().ApplicationV1alpha1().Apps(metav1.NamespaceAll)
+
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return apps.List(context.Background(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return apps.Watch(context.Background(), options)
+		},
+	}
+
+	w := &Watcher{
+		informer: cache.NewSharedIndexInformer(lw, &v1alpha1.App{}, config.ResyncPeriod, cache.Indexers{}),
+		logger:   config.Logger,
+
+		stop:          make(chan struct{}),
+		subscriptions: map[string]map[*Subscription]struct{}{},
+	}
+
+	w.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: w.notify,
+		UpdateFunc: func(_, newObj interface{}) {
+			w.notify(newObj)
+		},
+		DeleteFunc: w.notifyDeleted,
+	})
+
+	return w, nil
+}
+
+// Boot starts the informer.
+func (w *Watcher) Boot() {
+	w.bootOnce.Do(func() {
+		go w.informer.Run(w.stop)
+
+		go func() {
+			if cache.WaitForCacheSync(w.stop, w.informer.HasSynced) {
+				w.logger.Log("level", "debug", "message", "synced app CR cache")
+			}
+		}()
+	})
+}
+
+// Shutdown stops the informer.
+func (w *Watcher) Shutdown() {
+	w.shutdownOnce.Do(func() {
+		close(w.stop)
+	})
+}
+
+// Subscribe returns a subscription for the App CR with the given namespace
+// and name. The currently cached App CR, if any, is delivered right away.
+func (w *Watcher) Subscribe(namespace, name string) *Subscription {
+	key := namespace + "/" + name
+
+	s := &Subscription{
+		deleted: make(chan struct{}),
+		key:     key,
+		updates: make(chan *v1alpha1.App, 1),
+		watcher: w,
+	}
+
+	w.mutex.Lock()
+	if w.subscriptions[key] == nil {
+		w.subscriptions[key] = map[*Subscription]struct{}{}
+	}
+	w.subscriptions[key][s] = struct{}{}
+	w.mutex.Unlock()
+
+	obj, exists, err := w.informer.GetIndexer().GetByKey(key)
+	if err != nil {
+		w.logger.Log("level", "warning", "message", fmt.Sprintf("failed to get cached app CR %#q", key), "stack", fmt.Sprintf("%#v", err))
+	} else if exists {
+		w.notify(obj)
+	}
+
+	return s
+}
+
+func (w *Watcher) notify(obj interface{}) {
+	app, ok := obj.(*v1alpha1.App)
+	if !ok {
+		return
+	}
+
+	key := app.GetNamespace() + "/" + app.GetName()
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for s := range w.subscriptions[key] {
+		s.deliver(app.DeepCopy())
+	}
+}
+
+func (w *Watcher) notifyDeleted(obj interface{}) {
+	// The informer hands over the last known state when it missed the
+	// deletion itself, e.g. while re-establishing its watch.
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	app, ok := obj.(*v1alpha1.App)
+	if !ok {
+		return
+	}
+
+	key := app.GetNamespace() + "/" + app.GetName()
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for s := range w.subscriptions[key] {
+		s.markDeleted()
+	}
+}
+
+func (w *Watcher) unsubscribe(s *Subscription) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	delete(w.subscriptions[s.key], s)
+	if len(w.subscriptions[s.key]) == 0 {
+		delete(w.subscriptions, s.key)
+	}
+}
@@ -0,0 +1,50 @@
+package appwatcher
+
+import (
+	"sync"
+
+	"github.com/giantswarm/apiextensions/v3/pkg/apis/application/v1alpha1"
+)
+
+// Subscription receives the latest observed version of a single App CR.
+// Intermediate versions may be skipped when the subscriber is slower than
+// the updates of the App CR.
+type Subscription struct {
+	deleted     chan struct{}
+	deletedOnce sync.Once
+	key         string
+	updates     chan *v1alpha1.App
+	watcher     *Watcher
+}
+
+// Deleted returns a channel which is closed once the App CR got deleted.
+func (s *Subscription) Deleted() <-chan struct{} {
+	return s.deleted
+}
+
+// Updates returns the channel the latest observed App CR is delivered on.
+func (s *Subscription) Updates() <-chan *v1alpha1.App {
+	return s.updates
+}
+
+// Close stops delivering updates to the subscription.
+func (s *Subscription) Close() {
+	s.watcher.unsubscribe(s)
+}
+
+// deliver replaces any undelivered App CR with the given one so subscribers
+// always see the latest state without blocking the informer.
+func (s *Subscription) deliver(app *v1alpha1.App) {
+	select {
+	case <-s.updates:
+	default:
+	}
+
+	s.updates <- app
+}
+
+func (s *Subscription) markDeleted() {
+	s.deletedOnce.Do(func() {
+		close(s.deleted)
+	})
+}
@@ -0,0 +1,175 @@
+// Package catalog selects the catalog apps get deployed from by evaluating an
+// ordered, configurable set of rules.
+package catalog
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/giantswarm/microerror"
+	"sigs.k8s.io/yaml"
+
+	"github.com/giantswarm/app-checker/pkg/pattern"
+)
+
+// DefaultRules reproduce the catalog selection app-checker used before rules
+// became configurable.
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			Catalog:    "releases",
+			Repository: "releases",
+			Ref:        "master",
+		},
+		{
+			Catalog:    "releases-test",
+			Repository: "releases",
+		},
+		{
+			Catalog:    "control-plane-test-catalog",
+			Prerelease: boolPtr(true),
+		},
+		{
+			Catalog: "control-plane-catalog",
+		},
+	}
+}
+
+// ParseRules decodes rules from their YAML or JSON representation. Unknown
+// fields are rejected so typos do not silently widen a rule.
+func ParseRules(raw string) ([]Rule, error) {
+	var rules []Rule
+
+	err := yaml.UnmarshalStrict([]byte(raw), &rules)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "decoding catalog rules: %s", err)
+	}
+
+	return rules, nil
+}
+
+type Config struct {
+	Rules []Rule
+}
+
+type Router struct {
+	rules []rule
+}
+
+func New(config Config) (*Router, error) {
+	if len(config.Rules) == 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Rules must not be empty", config)
+	}
+
+	var rules []rule
+	for i, r := range config.Rules {
+		compiled, err := compile(r)
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "%T.Rules[%d]: %s", config, i, err)
+		}
+
+		rules = append(rules, compiled)
+	}
+
+	router := &Router{
+		rules: rules,
+	}
+
+	return router, nil
+}
+
+// Catalog returns the catalog of the first rule matching the given input.
+func (r *Router) Catalog(input Input) (string, error) {
+	v, err := semver.NewVersion(input.AppVersion)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	prerelease := v.Prerelease() != ""
+
+	for _, rule := range r.rules {
+		if rule.match(input, prerelease) {
+			return rule.catalog, nil
+		}
+	}
+
+	return "", microerror.Maskf(noMatchingRuleError, "repository %#q ref %#q version %#q", input.Repository, input.Ref, input.AppVersion)
+}
+
+type rule struct {
+	catalog string
+
+	environment *pattern.Pattern
+	payload     map[string]*pattern.Pattern
+	prerelease  *bool
+	ref         *pattern.Pattern
+	repository  *pattern.Pattern
+}
+
+func compile(r Rule) (rule, error) {
+	if r.Catalog == "" {
+		return rule{}, microerror.Maskf(invalidConfigError, "catalog must not be empty")
+	}
+
+	var err error
+
+	compiled := rule{
+		catalog:    r.Catalog,
+		payload:    map[string]*pattern.Pattern{},
+		prerelease: r.Prerelease,
+	}
+
+	if r.Environment != "" {
+		compiled.environment, err = pattern.Compile(r.Environment)
+		if err != nil {
+			return rule{}, microerror.Mask(err)
+		}
+	}
+	if r.Ref != "" {
+		compiled.ref, err = pattern.Compile(r.Ref)
+		if err != nil {
+			return rule{}, microerror.Mask(err)
+		}
+	}
+	if r.Repository != "" {
+		compiled.repository, err = pattern.Compile(r.Repository)
+		if err != nil {
+			return rule{}, microerror.Mask(err)
+		}
+	}
+	for k, v := range r.Payload {
+		compiled.payload[k], err = pattern.Compile(v)
+		if err != nil {
+			return rule{}, microerror.Mask(err)
+		}
+	}
+
+	return compiled, nil
+}
+
+func (r rule) match(input Input, prerelease bool) bool {
+	if r.environment != nil && !r.environment.Match(input.Environment) {
+		return false
+	}
+	if r.prerelease != nil && *r.prerelease != prerelease {
+		return false
+	}
+	if r.ref != nil && !r.ref.Match(input.Ref) {
+		return false
+	}
+	if r.repository != nil && !r.repository.Match(input.Repository) {
+		return false
+	}
+	for k, p := range r.payload {
+		v, ok := input.Payload[k]
+		if !ok || !p.Match(fmt.Sprint(v)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,414 @@
+package catalog
+
+import (
+	"testing"
+)
+
+func Test_Router_Catalog(t *testing.T) {
+	testCases := []struct {
+		name            string
+		rules           []Rule
+		input           Input
+		expectedCatalog string
+		errorMatcher    func(error) bool
+	}{
+		{
+			name: "case 0: glob repository",
+			rules: []Rule{
+				{Catalog: "operators", Repository: "*-operator"},
+				{Catalog: "fallback"},
+			},
+			input: Input{
+				AppVersion: "1.0.0",
+				Repository: "app-operator",
+			},
+			expectedCatalog: "operators",
+		},
+		{
+			name: "case 1: regexp repository",
+			rules: []Rule{
+				{Catalog: "collections", Repository: "/-app-collection$/"},
+				{Catalog: "fallback"},
+			},
+			input: Input{
+				AppVersion: "1.0.0",
+				Repository: "aws-app-collection",
+			},
+			expectedCatalog: "collections",
+		},
+		{
+			name: "case 2: regexp repository not matching",
+			rules: []Rule{
+				{Catalog: "collections", Repository: "/-app-collection$/"},
+				{Catalog: "fallback"},
+			},
+			input: Input{
+				AppVersion: "1.0.0",
+				Repository: "aws-app-collection-test",
+			},
+			expectedCatalog: "fallback",
+		},
+		{
+			name: "case 3: prerelease required",
+			rules: []Rule{
+				{Catalog: "test", Prerelease: boolPtr(true)},
+				{Catalog: "stable"},
+			},
+			input: Input{
+				AppVersion: "1.0.0-abcdef",
+			},
+			expectedCatalog: "test",
+		},
+		{
+			name: "case 4: prerelease required, release given",
+			rules: []Rule{
+				{Catalog: "test", Prerelease: boolPtr(true)},
+				{Catalog: "stable"},
+			},
+			input: Input{
+				AppVersion: "1.0.0",
+			},
+			expectedCatalog: "stable",
+		},
+		{
+			name: "case 5: release required, prerelease given",
+			rules: []Rule{
+				{Catalog: "stable", Prerelease: boolPtr(false)},
+				{Catalog: "test"},
+			},
+			input: Input{
+				AppVersion: "1.0.0-abcdef",
+			},
+			expectedCatalog: "test",
+		},
+		{
+			name: "case 6: ref condition",
+			rules: []Rule{
+				{Catalog: "main", Ref: "master"},
+				{Catalog: "branches"},
+			},
+			input: Input{
+				AppVersion: "1.0.0",
+				Ref:        "master",
+			},
+			expectedCatalog: "main",
+		},
+		{
+			name: "case 7: ref condition is anchored",
+			rules: []Rule{
+				{Catalog: "main", Ref: "master"},
+				{Catalog: "branches"},
+			},
+			input: Input{
+				AppVersion: "1.0.0",
+				Ref:        "master-fix",
+			},
+			expectedCatalog: "branches",
+		},
+		{
+			name: "case 8: environment condition",
+			rules: []Rule{
+				{Catalog: "aws", Environment: "aws-*"},
+				{Catalog: "fallback"},
+			},
+			input: Input{
+				AppVersion:  "1.0.0",
+				Environment: "aws-ginger",
+			},
+			expectedCatalog: "aws",
+		},
+		{
+			name: "case 9: environment condition not matching",
+			rules: []Rule{
+				{Catalog: "aws", Environment: "aws-*"},
+				{Catalog: "fallback"},
+			},
+			input: Input{
+				AppVersion:  "1.0.0",
+				Environment: "azure-godsmack",
+			},
+			expectedCatalog: "fallback",
+		},
+		{
+			name: "case 10: payload condition",
+			rules: []Rule{
+				{Catalog: "tenant", Payload: map[string]string{"cluster": "?????"}},
+				{Catalog: "fallback"},
+			},
+			input: Input{
+				AppVersion: "1.0.0",
+				Payload: map[string]interface{}{
+					"cluster": "a1b2c",
+				},
+			},
+			expectedCatalog: "tenant",
+		},
+		{
+			name: "case 11: payload condition with non string value",
+			rules: []Rule{
+				{Catalog: "forced", Payload: map[string]string{"force": "true"}},
+				{Catalog: "fallback"},
+			},
+			input: Input{
+				AppVersion: "1.0.0",
+				Payload: map[string]interface{}{
+					"force": true,
+				},
+			},
+			expectedCatalog: "forced",
+		},
+		{
+			name: "case 12: payload condition with missing field",
+			rules: []Rule{
+				{Catalog: "tenant", Payload: map[string]string{"cluster": "*"}},
+				{Catalog: "fallback"},
+			},
+			input: Input{
+				AppVersion: "1.0.0",
+				Payload:    map[string]interface{}{},
+			},
+			expectedCatalog: "fallback",
+		},
+		{
+			name: "case 13: all conditions have to match",
+			rules: []Rule{
+				{Catalog: "specific", Repository: "app-operator", Ref: "master", Prerelease: boolPtr(false)},
+				{Catalog: "fallback"},
+			},
+			input: Input{
+				AppVersion: "1.0.0",
+				Ref:        "master",
+				Repository: "chart-operator",
+			},
+			expectedCatalog: "fallback",
+		},
+		{
+			name: "case 14: first matching rule wins",
+			rules: []Rule{
+				{Catalog: "first", Repository: "app-*"},
+				{Catalog: "second", Repository: "app-operator"},
+				{Catalog: "fallback"},
+			},
+			input: Input{
+				AppVersion: "1.0.0",
+				Repository: "app-operator",
+			},
+			expectedCatalog: "first",
+		},
+		{
+			name: "case 15: no matching rule",
+			rules: []Rule{
+				{Catalog: "operators", Repository: "*-operator"},
+			},
+			input: Input{
+				AppVersion: "1.0.0",
+				Repository: "releases",
+			},
+			errorMatcher: IsNoMatchingRule,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := New(Config{Rules: tc.rules})
+			if err != nil {
+				t.Fatalf("expected nil, got %#v", err)
+			}
+
+			catalog, err := r.Catalog(tc.input)
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("expected nil, got %#v", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("expected error, got nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("unexpected error %#v", err)
+			}
+
+			if catalog != tc.expectedCatalog {
+				t.Fatalf("expected %#q, got %#q", tc.expectedCatalog, catalog)
+			}
+		})
+	}
+}
+
+// Test_DefaultRules verifies the default rules select the same catalogs as
+// the selection app-checker hard-coded before rules became configurable.
+func Test_DefaultRules(t *testing.T) {
+	testCases := []struct {
+		name            string
+		input           Input
+		expectedCatalog string
+	}{
+		{
+			name: "case 0: release",
+			input: Input{
+				AppVersion: "1.2.3",
+				Ref:        "master",
+				Repository: "app-operator",
+			},
+			expectedCatalog: "control-plane-catalog",
+		},
+		{
+			name: "case 1: prerelease",
+			input: Input{
+				AppVersion: "1.2.3-0123456789abcdef",
+				Ref:        "my-branch",
+				Repository: "app-operator",
+			},
+			expectedCatalog: "control-plane-test-catalog",
+		},
+		{
+			name: "case 2: releases repository on master",
+			input: Input{
+				AppVersion: "1.2.3",
+				Ref:        "master",
+				Repository: "releases",
+			},
+			expectedCatalog: "releases",
+		},
+		{
+			name: "case 3: releases repository prerelease on master",
+			input: Input{
+				AppVersion: "1.2.3-0123456789abcdef",
+				Ref:        "master",
+				Repository: "releases",
+			},
+			expectedCatalog: "releases",
+		},
+		{
+			name: "case 4: releases repository on branch",
+			input: Input{
+				AppVersion: "1.2.3",
+				Ref:        "my-branch",
+				Repository: "releases",
+			},
+			expectedCatalog: "releases-test",
+		},
+		{
+			name: "case 5: releases repository prerelease on branch",
+			input: Input{
+				AppVersion: "1.2.3-0123456789abcdef",
+				Ref:        "my-branch",
+				Repository: "releases",
+			},
+			expectedCatalog: "releases-test",
+		},
+	}
+
+	r, err := New(Config{Rules: DefaultRules()})
+	if err != nil {
+		t.Fatalf("expected nil, got %#v", err)
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			catalog, err := r.Catalog(tc.input)
+			if err != nil {
+				t.Fatalf("expected nil, got %#v", err)
+			}
+
+			if catalog != tc.expectedCatalog {
+				t.Fatalf("expected %#q, got %#q", tc.expectedCatalog, catalog)
+			}
+		})
+	}
+}
+
+func Test_ParseRules(t *testing.T) {
+	testCases := []struct {
+		name          string
+		raw           string
+		expectedRules []string
+		errorMatcher  func(error) bool
+	}{
+		{
+			name: "case 0: yaml rules",
+			raw: `
+- catalog: releases
+  repository: releases
+  ref: master
+- catalog: control-plane-catalog
+`,
+			expectedRules: []string{"releases", "control-plane-catalog"},
+		},
+		{
+			name:          "case 1: json rules",
+			raw:           `[{"catalog": "control-plane-test-catalog", "prerelease": true}]`,
+			expectedRules: []string{"control-plane-test-catalog"},
+		},
+		{
+			name: "case 2: unknown field",
+			raw: `
+- catalog: releases
+  repo: releases
+`,
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name:         "case 3: invalid type",
+			raw:          `[{"catalog": "releases", "prerelease": "yes"}]`,
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rules, err := ParseRules(tc.raw)
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("expected nil, got %#v", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("expected error, got nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("unexpected error %#v", err)
+			}
+
+			var catalogs []string
+			for _, r := range rules {
+				catalogs = append(catalogs, r.Catalog)
+			}
+
+			if len(catalogs) != len(tc.expectedRules) {
+				t.Fatalf("expected %v, got %v", tc.expectedRules, catalogs)
+			}
+			for i := range catalogs {
+				if catalogs[i] != tc.expectedRules[i] {
+					t.Fatalf("expected %v, got %v", tc.expectedRules, catalogs)
+				}
+			}
+		})
+	}
+}
+
+func Test_New_InvalidRules(t *testing.T) {
+	testCases := []struct {
+		name  string
+		rules []Rule
+	}{
+		{
+			name: "case 0: no rules",
+		},
+		{
+			name:  "case 1: empty catalog",
+			rules: []Rule{{Repository: "releases"}},
+		},
+		{
+			name:  "case 2: invalid pattern",
+			rules: []Rule{{Catalog: "releases", Repository: "/[a-/"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(Config{Rules: tc.rules})
+			if !IsInvalidConfig(err) {
+				t.Fatalf("expected invalidConfigError, got %#v", err)
+			}
+		})
+	}
+}
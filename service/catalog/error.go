@@ -0,0 +1,21 @@
+package catalog
+
+import "github.com/giantswarm/microerror"
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var noMatchingRuleError = &microerror.Error{
+	Kind: "noMatchingRuleError",
+}
+
+// IsNoMatchingRule asserts noMatchingRuleError.
+func IsNoMatchingRule(err error) bool {
+	return microerror.Cause(err) == noMatchingRuleError
+}
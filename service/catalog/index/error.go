@@ -0,0 +1,39 @@
+package index
+
+import "github.com/giantswarm/microerror"
+
+var catalogNotFoundError = &microerror.Error{
+	Kind: "catalogNotFoundError",
+}
+
+// IsCatalogNotFound asserts catalogNotFoundError.
+func IsCatalogNotFound(err error) bool {
+	return microerror.Cause(err) == catalogNotFoundError
+}
+
+var executionFailedError = &microerror.Error{
+	Kind: "executionFailedError",
+}
+
+// IsExecutionFailed asserts executionFailedError.
+func IsExecutionFailed(err error) bool {
+	return microerror.Cause(err) == executionFailedError
+}
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var versionNotFoundError = &microerror.Error{
+	Kind: "versionNotFoundError",
+}
+
+// IsVersionNotFound asserts versionNotFoundError.
+func IsVersionNotFound(err error) bool {
+	return microerror.Cause(err) == versionNotFoundError
+}
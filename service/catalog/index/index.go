@@ -0,0 +1,172 @@
+// Package index validates app versions against the Helm repository index of
+// the catalog they get deployed from.
+package index
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/k8sclient/v5/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// defaultRetryInterval is the interval in which the index of a catalog is
+	// fetched again while waiting for a missing version.
+	defaultRetryInterval = 15 * time.Second
+)
+
+type Config struct {
+	HTTPClient *http.Client
+	K8sClient  k8sclient.Interface
+	Logger     micrologger.Logger
+
+	// Wait is the time to wait for a missing version to get published to the
+	// catalog. Missing versions fail immediately when it is zero.
+	Wait time.Duration
+}
+
+type Index struct {
+	httpClient *http.Client
+	k8sClient  k8sclient.Interface
+	logger     micrologger.Logger
+
+	retryInterval time.Duration
+	wait          time.Duration
+}
+
+func New(config Config) (*Index, error) {
+	if config.HTTPClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.HTTPClient must not be empty", config)
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.Wait < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Wait must not be negative", config)
+	}
+
+	i := &Index{
+		httpClient: config.HTTPClient,
+		k8sClient:  config.K8sClient,
+		logger:     config.Logger,
+
+		retryInterval: defaultRetryInterval,
+		wait:          config.Wait,
+	}
+
+	return i, nil
+}
+
+// Validate checks that the given version of the app is published in the
+// catalog with the given name. The catalog is resolved from its AppCatalog or
+// Catalog CR. It returns catalogNotFoundError or versionNotFoundError when the
+// catalog or version does not exist.
+func (i *Index) Validate(ctx context.Context, catalog, app, version string) error {
+	url, err := i.catalogURL(ctx, catalog)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	deadline := time.Now().Add(i.wait)
+
+	for {
+		found, err := i.hasVersion(ctx, url, app, version)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if found {
+			return nil
+		}
+
+		if time.Now().Add(i.retryInterval).After(deadline) {
+			return microerror.Maskf(versionNotFoundError, "version %#q of app %#q not found in catalog %#q", version, app, catalog)
+		}
+
+		i.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("version %#q of app %#q not found in catalog %#q, retrying in %s", version, app, catalog, i.retryInterval))
+
+		select {
+		case <-time.After(i.retryInterval):
+		case <-ctx.Done():
+			return microerror.Mask(ctx.Err())
+		}
+	}
+}
+
+// catalogURL returns the storage URL of the catalog with the given name. The
+// cluster scoped AppCatalog CRs take precedence over namespaced Catalog CRs.
+func (i *Index) catalogURL(ctx context.Context, catalog string) (string, error) {
+	appCatalog, err := i.k8sClient.G8sClient().ApplicationV1alpha1().AppCatalogs().Get(ctx, catalog, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// fall through
+	} else if err != nil {
+		return "", microerror.Mask(err)
+	} else {
+		return appCatalog.Spec.Storage.URL, nil
+	}
+
+	lo := metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", catalog).String(),
+	}
+
+	catalogs, err := i.k8sClient.G8sClient().ApplicationV1alpha1().Catalogs(metav1.NamespaceAll).List(ctx, lo)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+	if len(catalogs.Items) == 0 {
+		return "", microerror.Maskf(catalogNotFoundError, "catalog %#q not found", catalog)
+	}
+
+	return catalogs.Items[0].Spec.Storage.URL, nil
+}
+
+// hasVersion returns whether the Helm repository index at the given catalog
+// URL has the given version of the app.
+func (i *Index) hasVersion(ctx context.Context, url, app, version string) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(url, "/")+"/index.yaml", nil)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	res, err := i.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return false, microerror.Maskf(executionFailedError, "fetching index of catalog %#q: %s", url, res.Status)
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	var index repositoryIndex
+	err = yaml.Unmarshal(body, &index)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	for _, entry := range index.Entries[app] {
+		if entry.Version == version {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,186 @@
+package index
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v3/pkg/apis/application/v1alpha1"
+	"github.com/giantswarm/apiextensions/v3/pkg/clientset/versioned/fake"
+	"github.com/giantswarm/k8sclient/v5/pkg/k8sclienttest"
+	"github.com/giantswarm/micrologger/microloggertest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	indexWithVersion = `
+apiVersion: v1
+entries:
+  app-operator:
+  - name: app-operator
+    version: 1.0.0
+  - name: app-operator
+    version: 1.1.0
+`
+	indexWithoutVersion = `
+apiVersion: v1
+entries:
+  app-operator:
+  - name: app-operator
+    version: 1.0.0
+`
+)
+
+func Test_Index_Validate(t *testing.T) {
+	testCases := []struct {
+		name string
+		// catalog is the name of the catalog the catalog CR of the test
+		// server is created with.
+		catalog string
+		// namespaced creates a Catalog CR instead of an AppCatalog CR.
+		namespaced bool
+		// responses are the index.yaml responses of the test server, one per
+		// request. The last one is repeated.
+		responses    []string
+		wait         time.Duration
+		errorMatcher func(error) bool
+	}{
+		{
+			name:      "case 0: version found",
+			catalog:   "control-plane-catalog",
+			responses: []string{indexWithVersion},
+		},
+		{
+			name:       "case 1: version found in namespaced catalog",
+			catalog:    "control-plane-catalog",
+			namespaced: true,
+			responses:  []string{indexWithVersion},
+		},
+		{
+			name:         "case 2: version not found",
+			catalog:      "control-plane-catalog",
+			responses:    []string{indexWithoutVersion},
+			errorMatcher: IsVersionNotFound,
+		},
+		{
+			name:         "case 3: catalog not found",
+			catalog:      "default",
+			responses:    []string{indexWithVersion},
+			errorMatcher: IsCatalogNotFound,
+		},
+		{
+			name:         "case 4: index not found",
+			catalog:      "control-plane-catalog",
+			responses:    []string{""},
+			errorMatcher: IsExecutionFailed,
+		},
+		{
+			name:      "case 5: version published while waiting",
+			catalog:   "control-plane-catalog",
+			responses: []string{indexWithoutVersion, indexWithoutVersion, indexWithVersion},
+			wait:      time.Minute,
+		},
+		{
+			name:         "case 6: version not published within wait",
+			catalog:      "control-plane-catalog",
+			responses:    []string{indexWithoutVersion},
+			wait:         50 * time.Millisecond,
+			errorMatcher: IsVersionNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			var requests int
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				if r.URL.Path != "/index.yaml" {
+					t.Errorf("expected request of %#q, got %#q", "/index.yaml", r.URL.Path)
+				}
+
+				response := tc.responses[len(tc.responses)-1]
+				if requests < len(tc.responses) {
+					response = tc.responses[requests]
+				}
+				requests++
+
+				if response == "" {
+					http.NotFound(w, r)
+					return
+				}
+
+				_, _ = w.Write([]byte(response))
+			}))
+			defer server.Close()
+
+			var object runtime.Object
+			if tc.namespaced {
+				object = &v1alpha1.Catalog{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      tc.catalog,
+						Namespace: "giantswarm",
+					},
+					Spec: v1alpha1.CatalogSpec{
+						Storage: v1alpha1.CatalogSpecStorage{
+							URL: server.URL + "/",
+						},
+					},
+				}
+			} else {
+				object = &v1alpha1.AppCatalog{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: tc.catalog,
+					},
+					Spec: v1alpha1.AppCatalogSpec{
+						Storage: v1alpha1.AppCatalogSpecStorage{
+							URL: server.URL + "/",
+						},
+					},
+				}
+			}
+
+			c := Config{
+				HTTPClient: server.Client(),
+				K8sClient: k8sclienttest.NewClients(k8sclienttest.ClientsConfig{
+					G8sClient: fake.NewSimpleClientset(object),
+				}),
+				Logger: microloggertest.New(),
+
+				Wait: tc.wait,
+			}
+
+			i, err := New(c)
+			if err != nil {
+				t.Fatalf("expected nil, got %#v", err)
+			}
+			i.retryInterval = 10 * time.Millisecond
+
+			err = i.Validate(context.Background(), "control-plane-catalog", "app-operator", "1.1.0")
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("expected nil, got %#v", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("expected error, got nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("unexpected error %#v", err)
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if len(tc.responses) > 1 && requests != len(tc.responses) {
+				t.Fatalf("expected %d requests, got %d", len(tc.responses), requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package index
+
+// repositoryIndex is the subset of a Helm repository index.yaml the
+// validation needs.
+type repositoryIndex struct {
+	Entries map[string][]repositoryIndexEntry `json:"entries"`
+}
+
+type repositoryIndexEntry struct {
+	Version string `json:"version"`
+}
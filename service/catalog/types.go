@@ -0,0 +1,28 @@
+package catalog
+
+// Rule selects Catalog for deployments matching all of its conditions. Empty
+// conditions match everything. Repository, Ref, Environment and Payload values
+// are glob patterns.
+type Rule struct {
+	Catalog string `json:"catalog"`
+
+	// Environment is matched against the installation app-checker runs in.
+	Environment string `json:"environment,omitempty"`
+	// Payload maps fields of the deployment payload to the patterns their
+	// values have to match.
+	Payload map[string]string `json:"payload,omitempty"`
+	// Prerelease, when set, requires the app version to be a semver
+	// prerelease or not.
+	Prerelease *bool  `json:"prerelease,omitempty"`
+	Ref        string `json:"ref,omitempty"`
+	Repository string `json:"repository,omitempty"`
+}
+
+// Input describes the deployment a catalog is selected for.
+type Input struct {
+	AppVersion  string
+	Environment string
+	Payload     map[string]interface{}
+	Ref         string
+	Repository  string
+}
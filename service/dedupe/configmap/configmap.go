@@ -0,0 +1,181 @@
+// Package configmap implements a webhook delivery dedupe backend which keeps
+// every delivery in its own ConfigMap.
+package configmap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/k8sclient/v5/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/giantswarm/app-checker/pkg/label"
+	"github.com/giantswarm/app-checker/pkg/project"
+	"github.com/giantswarm/app-checker/service/dedupe"
+)
+
+const (
+	deliveryLabel = "app-checker.giantswarm.io/delivery"
+	entryKey      = "entry"
+	// receivedAtAnnotation allows pruning expired deliveries without
+	// decoding their entries.
+	receivedAtAnnotation = "app-checker.giantswarm.io/received-at"
+)
+
+type Config struct {
+	K8sClient k8sclient.Interface
+	Logger    micrologger.Logger
+
+	Namespace string
+}
+
+type Backend struct {
+	k8sClient k8sclient.Interface
+	logger    micrologger.Logger
+
+	namespace string
+}
+
+func New(config Config) (*Backend, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Namespace must not be empty", config)
+	}
+
+	b := &Backend{
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+
+		namespace: config.Namespace,
+	}
+
+	return b, nil
+}
+
+func (b *Backend) Get(ctx context.Context, deliveryID string) (*dedupe.Entry, error) {
+	n, err := name(deliveryID)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	cm, err := b.k8sClient.K8sClient().CoreV1().ConfigMaps(b.namespace).Get(ctx, n, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var entry dedupe.Entry
+	err = json.Unmarshal([]byte(cm.Data[entryKey]), &entry)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return &entry, nil
+}
+
+func (b *Backend) Prune(ctx context.Context, before time.Time) error {
+	lo := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true", deliveryLabel),
+	}
+
+	list, err := b.k8sClient.K8sClient().CoreV1().ConfigMaps(b.namespace).List(ctx, lo)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, cm := range list.Items {
+		receivedAt, err := time.Parse(time.RFC3339, cm.Annotations[receivedAtAnnotation])
+		if err == nil && receivedAt.After(before) {
+			continue
+		}
+
+		err = b.k8sClient.K8sClient().CoreV1().ConfigMaps(b.namespace).Delete(ctx, cm.Name, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+func (b *Backend) Put(ctx context.Context, deliveryID string, entry dedupe.Entry) error {
+	n, err := name(deliveryID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      n,
+			Namespace: b.namespace,
+			Annotations: map[string]string{
+				receivedAtAnnotation: entry.ReceivedAt.UTC().Format(time.RFC3339),
+			},
+			Labels: map[string]string{
+				deliveryLabel:   "true",
+				label.ManagedBy: label.ManagedByValue(),
+			},
+		},
+		Data: map[string]string{
+			entryKey: string(data),
+		},
+	}
+
+	_, err = b.k8sClient.K8sClient().CoreV1().ConfigMaps(b.namespace).Create(ctx, cm, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		// Another replica may update the same delivery concurrently, e.g.
+		// when GitHub redelivers it while it is still being handled.
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			current, err := b.k8sClient.K8sClient().CoreV1().ConfigMaps(b.namespace).Get(ctx, cm.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			cm.ResourceVersion = current.ResourceVersion
+
+			_, err = b.k8sClient.K8sClient().CoreV1().ConfigMaps(b.namespace).Update(ctx, cm, metav1.UpdateOptions{})
+			return err
+		})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// name returns the name of the ConfigMap of the given delivery ID. GitHub
+// delivery IDs are GUIDs, which are valid ConfigMap names once lowercased.
+func name(deliveryID string) (string, error) {
+	n := fmt.Sprintf("%s-delivery-%s", project.Name(), strings.ToLower(deliveryID))
+	if len(validation.IsDNS1123Subdomain(n)) > 0 {
+		return "", microerror.Maskf(invalidDeliveryError, "delivery ID %#q cannot be persisted", deliveryID)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,223 @@
+package configmap
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/k8sclient/v5/pkg/k8sclienttest"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/giantswarm/app-checker/service/dedupe"
+)
+
+func Test_Backend_Put(t *testing.T) {
+	testCases := []struct {
+		name       string
+		deliveryID string
+		objects    []runtime.Object
+		// conflicts is the number of ConfigMap updates failing with a
+		// conflict.
+		conflicts       int
+		expectedMessage string
+		errorMatcher    func(error) bool
+	}{
+		{
+			name:            "case 0: delivery persisted",
+			deliveryID:      "72D3162E-CC78-11E3-81AB-4C9367DC0958",
+			expectedMessage: "deployment queued",
+		},
+		{
+			name:       "case 1: delivery persisted already overwritten",
+			deliveryID: "72D3162E-CC78-11E3-81AB-4C9367DC0958",
+			objects: []runtime.Object{
+				testConfigMap("72d3162e-cc78-11e3-81ab-4c9367dc0958", dedupe.Entry{ReceivedAt: time.Now()}),
+			},
+			expectedMessage: "deployment queued",
+		},
+		{
+			name:       "case 2: conflicting update of delivery persisted already retried",
+			deliveryID: "72D3162E-CC78-11E3-81AB-4C9367DC0958",
+			objects: []runtime.Object{
+				testConfigMap("72d3162e-cc78-11e3-81ab-4c9367dc0958", dedupe.Entry{ReceivedAt: time.Now()}),
+			},
+			conflicts:       2,
+			expectedMessage: "deployment queued",
+		},
+		{
+			name:       "case 3: persistently conflicting update of delivery persisted already",
+			deliveryID: "72D3162E-CC78-11E3-81AB-4C9367DC0958",
+			objects: []runtime.Object{
+				testConfigMap("72d3162e-cc78-11e3-81ab-4c9367dc0958", dedupe.Entry{ReceivedAt: time.Now()}),
+			},
+			conflicts:    100,
+			errorMatcher: func(err error) bool { return apierrors.IsConflict(microerror.Cause(err)) },
+		},
+		{
+			name:         "case 4: invalid delivery ID",
+			deliveryID:   "../delivery",
+			errorMatcher: IsInvalidDelivery,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			k8sClient := fake.NewSimpleClientset(tc.objects...)
+			conflicts := tc.conflicts
+			k8sClient.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if conflicts == 0 {
+					return false, nil, nil
+				}
+				conflicts--
+
+				return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, action.(k8stesting.UpdateAction).GetObject().(*corev1.ConfigMap).Name, nil)
+			})
+
+			b := testBackend(k8sClient)
+
+			err := b.Put(ctx, tc.deliveryID, dedupe.Entry{Message: "deployment queued", ReceivedAt: time.Now()})
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if tc.expectedMessage == "" {
+				return
+			}
+
+			entry, err := b.Get(ctx, tc.deliveryID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry == nil {
+				t.Fatalf("entry == nil, want non-nil")
+			}
+			if entry.Message != tc.expectedMessage {
+				t.Fatalf("message == %#q, want %#q", entry.Message, tc.expectedMessage)
+			}
+		})
+	}
+}
+
+func Test_Backend_Get(t *testing.T) {
+	testCases := []struct {
+		name            string
+		objects         []runtime.Object
+		expectedMessage string
+	}{
+		{
+			name: "case 0: unknown delivery",
+		},
+		{
+			name: "case 1: persisted delivery",
+			objects: []runtime.Object{
+				testConfigMap("1", dedupe.Entry{Message: "deployment queued", ReceivedAt: time.Now()}),
+			},
+			expectedMessage: "deployment queued",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := testBackend(fake.NewSimpleClientset(tc.objects...))
+
+			entry, err := b.Get(context.Background(), "1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.expectedMessage == "" {
+				if entry != nil {
+					t.Fatalf("entry == %#v, want nil", entry)
+				}
+				return
+			}
+
+			if entry == nil {
+				t.Fatalf("entry == nil, want non-nil")
+			}
+			if entry.Message != tc.expectedMessage {
+				t.Fatalf("message == %#q, want %#q", entry.Message, tc.expectedMessage)
+			}
+		})
+	}
+}
+
+func Test_Backend_Prune(t *testing.T) {
+	now := time.Now()
+
+	invalid := testConfigMap("3", dedupe.Entry{})
+	invalid.Annotations[receivedAtAnnotation] = "invalid"
+
+	k8sClient := fake.NewSimpleClientset(
+		testConfigMap("1", dedupe.Entry{ReceivedAt: now.Add(-2 * time.Hour)}),
+		testConfigMap("2", dedupe.Entry{ReceivedAt: now.Add(-time.Minute)}),
+		invalid,
+	)
+
+	b := testBackend(k8sClient)
+
+	err := b.Prune(context.Background(), now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := k8sClient.CoreV1().ConfigMaps("giantswarm").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Name != "app-checker-delivery-2" {
+		t.Fatalf("ConfigMaps == %v, want only %#q", list.Items, "app-checker-delivery-2")
+	}
+}
+
+func testBackend(k8sClient *fake.Clientset) *Backend {
+	return &Backend{
+		k8sClient: k8sclienttest.NewClients(k8sclienttest.ClientsConfig{
+			K8sClient: k8sClient,
+		}),
+		logger: microloggertest.New(),
+
+		namespace: "giantswarm",
+	}
+}
+
+func testConfigMap(deliveryID string, entry dedupe.Entry) *corev1.ConfigMap {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		panic(err)
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app-checker-delivery-" + deliveryID,
+			Namespace: "giantswarm",
+			Annotations: map[string]string{
+				receivedAtAnnotation: entry.ReceivedAt.UTC().Format(time.RFC3339),
+			},
+			Labels: map[string]string{
+				deliveryLabel: "true",
+			},
+		},
+		Data: map[string]string{
+			entryKey: string(data),
+		},
+	}
+}
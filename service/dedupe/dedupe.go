@@ -0,0 +1,156 @@
+// Package dedupe recognizes redeliveries of GitHub webhook deliveries by their
+// `X-GitHub-Delivery` ID. Entries are kept in memory and, optionally, in a
+// persistent backend for a bounded time.
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+)
+
+const (
+	// pruneInterval is the minimum interval between pruning expired
+	// entries.
+	pruneInterval = 10 * time.Minute
+)
+
+type Config struct {
+	// Backend persists entries across restarts. Entries are only kept in
+	// memory when it is nil.
+	Backend Backend
+	Logger  micrologger.Logger
+
+	// TTL is the time entries are kept after their delivery was received.
+	TTL time.Duration
+}
+
+type Cache struct {
+	backend Backend
+	logger  micrologger.Logger
+
+	ttl time.Duration
+
+	entries   map[string]Entry
+	lastPrune time.Time
+	mutex     sync.Mutex
+}
+
+func New(config Config) (*Cache, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.TTL <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.TTL must be greater than zero", config)
+	}
+
+	c := &Cache{
+		backend: config.Backend,
+		logger:  config.Logger,
+
+		ttl: config.TTL,
+
+		entries:   map[string]Entry{},
+		lastPrune: time.Now(),
+	}
+
+	return c, nil
+}
+
+// Reserve atomically checks whether the delivery with the given ID was
+// handled within the TTL and otherwise reserves it for the caller. The entry
+// of an earlier delivery is returned together with true. A reserved delivery
+// is answered as being handled until the caller records its outcome with Put
+// or gives it up with Release. Failures of the backend are logged and treated
+// as unknown delivery, so webhook deliveries are never rejected because of
+// them.
+func (c *Cache) Reserve(ctx context.Context, deliveryID string, pending Entry) (Entry, bool) {
+	if deliveryID == "" {
+		return Entry{}, false
+	}
+
+	c.mutex.Lock()
+	entry, ok := c.entries[deliveryID]
+	if ok && !c.expired(entry) {
+		c.mutex.Unlock()
+		return entry, true
+	}
+	c.entries[deliveryID] = pending
+	c.mutex.Unlock()
+
+	if c.backend == nil {
+		return Entry{}, false
+	}
+
+	persisted, err := c.backend.Get(ctx, deliveryID)
+	if err != nil {
+		c.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to get delivery %#q", deliveryID), "stack", fmt.Sprintf("%#v", err))
+		return Entry{}, false
+	}
+	if persisted == nil || c.expired(*persisted) {
+		return Entry{}, false
+	}
+
+	c.mutex.Lock()
+	c.entries[deliveryID] = *persisted
+	c.mutex.Unlock()
+
+	return *persisted, true
+}
+
+// Release gives up the reservation of the delivery with the given ID, so a
+// redelivery is handled again.
+func (c *Cache) Release(deliveryID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.entries, deliveryID)
+}
+
+// Put records the entry of the delivery with the given ID. Expired entries
+// are pruned on the way.
+func (c *Cache) Put(ctx context.Context, deliveryID string, entry Entry) {
+	if deliveryID == "" {
+		return
+	}
+
+	c.mutex.Lock()
+	c.entries[deliveryID] = entry
+	prune := time.Since(c.lastPrune) > pruneInterval
+	if prune {
+		c.lastPrune = time.Now()
+		for id, e := range c.entries {
+			if c.expired(e) {
+				delete(c.entries, id)
+			}
+		}
+	}
+	c.mutex.Unlock()
+
+	if c.backend == nil {
+		return
+	}
+
+	err := c.backend.Put(ctx, deliveryID, entry)
+	if err != nil {
+		c.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to persist delivery %#q", deliveryID), "stack", fmt.Sprintf("%#v", err))
+	}
+
+	if prune {
+		go func() {
+			err := c.backend.Prune(context.Background(), time.Now().Add(-c.ttl))
+			if err != nil {
+				c.logger.Log("level", "warning", "message", "failed to prune expired deliveries", "stack", fmt.Sprintf("%#v", err))
+			}
+		}()
+	}
+}
+
+func (c *Cache) expired(entry Entry) bool {
+	return time.Since(entry.ReceivedAt) > c.ttl
+}
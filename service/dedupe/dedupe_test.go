@@ -0,0 +1,279 @@
+package dedupe
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+)
+
+func Test_Cache_Reserve(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name       string
+		deliveryID string
+		// entries are the entries kept in memory beforehand.
+		entries map[string]Entry
+		// persisted are the entries of the backend. The cache has no backend
+		// when both persisted and backendErr are nil.
+		persisted       map[string]Entry
+		backendErr      error
+		expectedFound   bool
+		expectedMessage string
+	}{
+		{
+			name:          "case 0: unknown delivery reserved",
+			deliveryID:    "1",
+			expectedFound: false,
+		},
+		{
+			name:       "case 1: handled delivery found",
+			deliveryID: "1",
+			entries: map[string]Entry{
+				"1": {Message: "deployment queued", ReceivedAt: now.Add(-time.Minute)},
+			},
+			expectedFound:   true,
+			expectedMessage: "deployment queued",
+		},
+		{
+			name:       "case 2: expired delivery reserved again",
+			deliveryID: "1",
+			entries: map[string]Entry{
+				"1": {Message: "deployment queued", ReceivedAt: now.Add(-2 * time.Hour)},
+			},
+			expectedFound: false,
+		},
+		{
+			name:       "case 3: delivery handled before restart found in backend",
+			deliveryID: "1",
+			persisted: map[string]Entry{
+				"1": {Message: "deployment queued", ReceivedAt: now.Add(-time.Minute)},
+			},
+			expectedFound:   true,
+			expectedMessage: "deployment queued",
+		},
+		{
+			name:       "case 4: expired delivery in backend reserved again",
+			deliveryID: "1",
+			persisted: map[string]Entry{
+				"1": {Message: "deployment queued", ReceivedAt: now.Add(-2 * time.Hour)},
+			},
+			expectedFound: false,
+		},
+		{
+			name:          "case 5: backend failure treated as unknown delivery",
+			deliveryID:    "1",
+			backendErr:    errors.New("test error"),
+			expectedFound: false,
+		},
+		{
+			name:          "case 6: delivery without ID never reserved",
+			deliveryID:    "",
+			expectedFound: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			config := Config{
+				Logger: microloggertest.New(),
+				TTL:    time.Hour,
+			}
+			if tc.persisted != nil || tc.backendErr != nil {
+				config.Backend = &testBackend{entries: tc.persisted, err: tc.backendErr}
+			}
+
+			c, err := New(config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for id, entry := range tc.entries {
+				c.entries[id] = entry
+			}
+
+			pending := Entry{ReceivedAt: now}
+
+			entry, found := c.Reserve(ctx, tc.deliveryID, pending)
+			if found != tc.expectedFound {
+				t.Fatalf("found == %t, want %t", found, tc.expectedFound)
+			}
+			if entry.Message != tc.expectedMessage {
+				t.Fatalf("message == %#q, want %#q", entry.Message, tc.expectedMessage)
+			}
+
+			// Redeliveries arriving while the delivery is handled are
+			// answered from the reservation.
+			_, found = c.Reserve(ctx, tc.deliveryID, pending)
+			if found != (tc.deliveryID != "") {
+				t.Fatalf("found == %t, want %t", found, tc.deliveryID != "")
+			}
+		})
+	}
+}
+
+func Test_Cache_Release(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := New(Config{
+		Logger: microloggertest.New(),
+		TTL:    time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, found := c.Reserve(ctx, "1", Entry{ReceivedAt: time.Now()})
+	if found {
+		t.Fatalf("found == true, want false")
+	}
+
+	c.Release("1")
+
+	_, found = c.Reserve(ctx, "1", Entry{ReceivedAt: time.Now()})
+	if found {
+		t.Fatalf("found == true, want false")
+	}
+}
+
+func Test_Cache_Put(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name      string
+		entries   map[string]Entry
+		lastPrune time.Time
+		// expectedEntries are the delivery IDs kept in memory afterwards.
+		expectedEntries []string
+		expectedPruned  bool
+	}{
+		{
+			name: "case 0: expired entries pruned",
+			entries: map[string]Entry{
+				"1": {ReceivedAt: now.Add(-2 * time.Hour)},
+				"2": {ReceivedAt: now.Add(-time.Minute)},
+			},
+			lastPrune:       now.Add(-2 * pruneInterval),
+			expectedEntries: []string{"2", "3"},
+			expectedPruned:  true,
+		},
+		{
+			name: "case 1: not pruned within the prune interval",
+			entries: map[string]Entry{
+				"1": {ReceivedAt: now.Add(-2 * time.Hour)},
+			},
+			lastPrune:       now.Add(-time.Minute),
+			expectedEntries: []string{"1", "3"},
+			expectedPruned:  false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &testBackend{
+				entries: map[string]Entry{},
+				pruned:  make(chan time.Time, 1),
+			}
+
+			c, err := New(Config{
+				Backend: b,
+				Logger:  microloggertest.New(),
+				TTL:     time.Hour,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for id, entry := range tc.entries {
+				c.entries[id] = entry
+			}
+			c.lastPrune = tc.lastPrune
+
+			c.Put(context.Background(), "3", Entry{Message: "deployment queued", ReceivedAt: now})
+
+			c.mutex.Lock()
+			if len(c.entries) != len(tc.expectedEntries) {
+				t.Fatalf("entries == %v, want %v", c.entries, tc.expectedEntries)
+			}
+			for _, id := range tc.expectedEntries {
+				if _, ok := c.entries[id]; !ok {
+					t.Fatalf("entries == %v, want %v", c.entries, tc.expectedEntries)
+				}
+			}
+			c.mutex.Unlock()
+
+			persisted, err := b.Get(context.Background(), "3")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if persisted == nil || persisted.Message != "deployment queued" {
+				t.Fatalf("persisted == %#v, want entry", persisted)
+			}
+
+			select {
+			case before := <-b.pruned:
+				if !tc.expectedPruned {
+					t.Fatalf("backend pruned, want not pruned")
+				}
+				if before.After(time.Now().Add(-time.Hour)) {
+					t.Fatalf("pruned before %s, want entries older than the TTL", before)
+				}
+			case <-time.After(100 * time.Millisecond):
+				if tc.expectedPruned {
+					t.Fatalf("backend not pruned, want pruned")
+				}
+			}
+		})
+	}
+}
+
+// testBackend is an in-memory Backend.
+type testBackend struct {
+	mutex   sync.Mutex
+	entries map[string]Entry
+	err     error
+
+	// pruned receives the time passed to Prune.
+	pruned chan time.Time
+}
+
+func (b *testBackend) Get(ctx context.Context, deliveryID string) (*Entry, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	entry, ok := b.entries[deliveryID]
+	if !ok {
+		return nil, nil
+	}
+
+	return &entry, nil
+}
+
+func (b *testBackend) Prune(ctx context.Context, before time.Time) error {
+	if b.pruned != nil {
+		b.pruned <- before
+	}
+
+	return b.err
+}
+
+func (b *testBackend) Put(ctx context.Context, deliveryID string, entry Entry) error {
+	if b.err != nil {
+		return b.err
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.entries[deliveryID] = entry
+
+	return nil
+}
@@ -0,0 +1,19 @@
+package dedupe
+
+import (
+	"context"
+	"time"
+)
+
+// Backend persists the entries of handled webhook deliveries so redeliveries
+// are recognized across restarts of app-checker.
+type Backend interface {
+	// Get returns the entry of the given delivery ID, or nil when there is
+	// none.
+	Get(ctx context.Context, deliveryID string) (*Entry, error)
+	// Prune deletes all entries received before the given time.
+	Prune(ctx context.Context, before time.Time) error
+	// Put persists the entry of the given delivery ID, overwriting any entry
+	// with the same delivery ID.
+	Put(ctx context.Context, deliveryID string, entry Entry) error
+}
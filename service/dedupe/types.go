@@ -0,0 +1,15 @@
+package dedupe
+
+import "time"
+
+// Entry records how a webhook delivery was handled.
+type Entry struct {
+	// DeploymentIDs are the GitHub deployments the delivery queued.
+	DeploymentIDs []int64 `json:"deploymentIDs,omitempty"`
+	EventType     string  `json:"eventType"`
+	// Message is the message the delivery was answered with. It is empty
+	// while the delivery is being handled.
+	Message    string    `json:"message"`
+	ReceivedAt time.Time `json:"receivedAt"`
+	Repository string    `json:"repository"`
+}
@@ -0,0 +1,77 @@
+// Package deliveries keeps track of the webhook deliveries received per event
+// type, so operators can confirm the GitHub webhook is wired up correctly.
+package deliveries
+
+import (
+	"sync"
+	"time"
+)
+
+type Config struct {
+}
+
+type Recorder struct {
+	events map[string]*Event
+	mutex  sync.Mutex
+}
+
+func New(config Config) (*Recorder, error) {
+	r := &Recorder{
+		events: map[string]*Event{},
+	}
+
+	return r, nil
+}
+
+// Received records a delivery of the given event type with a valid signature.
+func (r *Recorder) Received(eventType, deliveryID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+
+	e := r.event(eventType)
+	e.LastDeliveryID = deliveryID
+	e.LastReceivedAt = &now
+	e.Received++
+}
+
+// Rejected records a delivery of the given event type which was rejected for
+// the given reason, e.g. because of an invalid signature.
+func (r *Recorder) Rejected(eventType, reason string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+
+	e := r.event(eventType)
+	e.LastRejectedAt = &now
+	e.LastRejectedReason = reason
+	e.Rejected++
+}
+
+// List returns copies of the summaries of all event types received so far,
+// keyed by event type.
+func (r *Recorder) List() map[string]Event {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	events := make(map[string]Event, len(r.events))
+	for t, e := range r.events {
+		events[t] = *e
+	}
+
+	return events
+}
+
+// event returns the summary of the given event type. The caller must hold
+// r.mutex.
+func (r *Recorder) event(eventType string) *Event {
+	e, ok := r.events[eventType]
+	if !ok {
+		e = &Event{}
+		r.events[eventType] = e
+	}
+
+	return e
+}
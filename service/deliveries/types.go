@@ -0,0 +1,15 @@
+package deliveries
+
+import "time"
+
+// Event summarizes the webhook deliveries of a single event type.
+type Event struct {
+	// LastDeliveryID is the GUID of the latest accepted delivery.
+	LastDeliveryID string     `json:"lastDeliveryID,omitempty"`
+	LastReceivedAt *time.Time `json:"lastReceivedAt,omitempty"`
+	Received       int64      `json:"received"`
+
+	LastRejectedAt     *time.Time `json:"lastRejectedAt,omitempty"`
+	LastRejectedReason string     `json:"lastRejectedReason,omitempty"`
+	Rejected           int64      `json:"rejected"`
+}
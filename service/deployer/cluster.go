@@ -0,0 +1,136 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/giantswarm/apiextensions/v3/pkg/apis/application/v1alpha1"
+	"github.com/giantswarm/app/v4/pkg/key"
+	k8smetadatalabel "github.com/giantswarm/k8smetadata/pkg/label"
+	"github.com/giantswarm/microerror"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// kubeConfigKey is the key of the kubeconfig in the kubeconfig Secret of
+	// a workload cluster.
+	kubeConfigKey = "kubeConfig"
+)
+
+// configureClusterApp completes the given App CR for the given workload
+// cluster the way app-operator expects it. By convention the App CRs of a
+// workload cluster, its kubeconfig Secret and its cluster values live in a
+// namespace named after the cluster ID. The App CR is reconciled by the
+// app-operator of the cluster, whose version is taken from the chart-operator
+// App CR of the cluster.
+func (d *Deployer) configureClusterApp(ctx context.Context, clusterID string, cr *v1alpha1.App) error {
+	_, err := d.k8sClient.K8sClient().CoreV1().Namespaces().Get(ctx, clusterID, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return microerror.Maskf(invalidDeploymentError, "cluster %#q not found", clusterID)
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	kubeConfig, err := d.clusterKubeConfig(ctx, clusterID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	version, err := d.clusterAppOperatorVersion(ctx, clusterID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	configMapName := key.ClusterValuesConfigMapName(*cr)
+
+	_, err = d.k8sClient.K8sClient().CoreV1().ConfigMaps(clusterID).Get(ctx, configMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return microerror.Maskf(invalidDeploymentError, "cluster values ConfigMap %#q of cluster %#q not found", configMapName, clusterID)
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	cr.Spec.Config.ConfigMap = v1alpha1.AppSpecConfigConfigMap{
+		Name:      configMapName,
+		Namespace: clusterID,
+	}
+	cr.Spec.KubeConfig = kubeConfig
+
+	if cr.Labels == nil {
+		cr.Labels = map[string]string{}
+	}
+	cr.Labels[k8smetadatalabel.AppOperatorVersion] = version
+	cr.Labels[k8smetadatalabel.Cluster] = clusterID
+
+	return nil
+}
+
+// clusterAppOperatorVersion returns the version of the app-operator
+// reconciling the App CRs of the given workload cluster.
+func (d *Deployer) clusterAppOperatorVersion(ctx context.Context, clusterID string) (string, error) {
+	cr, err := d.k8sClient.G8sClient().ApplicationV1alpha1().Apps(clusterID).Get(ctx, key.ChartOperatorAppName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", microerror.Maskf(invalidDeploymentError, "App CR %#q of cluster %#q not found", key.ChartOperatorAppName, clusterID)
+	} else if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	version := cr.Labels[k8smetadatalabel.AppOperatorVersion]
+	if version == "" {
+		return "", microerror.Maskf(invalidDeploymentError, "App CR %#q of cluster %#q has no label %#q", key.ChartOperatorAppName, clusterID, k8smetadatalabel.AppOperatorVersion)
+	}
+
+	return version, nil
+}
+
+// clusterKubeConfig returns the kubeconfig of the App CRs of the given
+// workload cluster. The context is the current context of the kubeconfig
+// stored in the kubeconfig Secret of the cluster.
+func (d *Deployer) clusterKubeConfig(ctx context.Context, clusterID string) (v1alpha1.AppSpecKubeConfig, error) {
+	secretName := kubeConfigSecretName(clusterID)
+
+	secret, err := d.k8sClient.K8sClient().CoreV1().Secrets(clusterID).Get(ctx, secretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return v1alpha1.AppSpecKubeConfig{}, microerror.Maskf(invalidDeploymentError, "kubeconfig Secret %#q of cluster %#q not found", secretName, clusterID)
+	} else if err != nil {
+		return v1alpha1.AppSpecKubeConfig{}, microerror.Mask(err)
+	}
+
+	config, err := clientcmd.Load(secret.Data[kubeConfigKey])
+	if err != nil {
+		return v1alpha1.AppSpecKubeConfig{}, microerror.Maskf(invalidDeploymentError, "kubeconfig Secret %#q of cluster %#q is invalid", secretName, clusterID)
+	}
+	if config.CurrentContext == "" {
+		return v1alpha1.AppSpecKubeConfig{}, microerror.Maskf(invalidDeploymentError, "kubeconfig Secret %#q of cluster %#q has no current context", secretName, clusterID)
+	}
+
+	kubeConfig := v1alpha1.AppSpecKubeConfig{
+		Context: v1alpha1.AppSpecKubeConfigContext{
+			Name: config.CurrentContext,
+		},
+		InCluster: false,
+		Secret: v1alpha1.AppSpecKubeConfigSecret{
+			Name:      secretName,
+			Namespace: clusterID,
+		},
+	}
+
+	return kubeConfig, nil
+}
+
+func kubeConfigSecretName(clusterID string) string {
+	return fmt.Sprintf("%s-kubeconfig", clusterID)
+}
+
+// toAppCRNamespace returns the namespace of the App CR the given deployment
+// is deployed to. App CRs of the management cluster live in the namespace of
+// the app, the ones of workload clusters in the cluster namespace.
+func toAppCRNamespace(payload *Payload) string {
+	if payload.Cluster != "" {
+		return payload.Cluster
+	}
+
+	return payload.Namespace
+}
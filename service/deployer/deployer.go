@@ -0,0 +1,756 @@
+// Package deployer creates or updates App CRs for GitHub deployment events and
+// reports the resulting app status back to GitHub.
+package deployer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v3/pkg/apis/application/v1alpha1"
+	"github.com/giantswarm/app/v4/pkg/app"
+	"github.com/giantswarm/k8sclient/v5/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-github/v32/github"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/giantswarm/app-checker/pkg/githubapp"
+	"github.com/giantswarm/app-checker/service/appwatcher"
+	"github.com/giantswarm/app-checker/service/catalog"
+	"github.com/giantswarm/app-checker/service/catalog/index"
+	"github.com/giantswarm/app-checker/service/history"
+	"github.com/giantswarm/app-checker/service/reporter"
+	"github.com/giantswarm/app-checker/service/tracker"
+)
+
+const (
+	releases = "releases"
+)
+
+type Config struct {
+	AppWatcher *appwatcher.Watcher
+	// CatalogIndex validates that the deployed version is published in the
+	// catalog. Validation is disabled when it is nil.
+	CatalogIndex  *index.Index
+	CatalogRouter *catalog.Router
+	GithubClient  *github.Client
+	History       *history.Recorder
+	K8sClient     k8sclient.Interface
+	Logger        micrologger.Logger
+	Reporter      *reporter.Reporter
+	Tracker       *tracker.Tracker
+
+	// AutoInactive marks older successful deployments of the same App CR
+	// inactive once a deployment succeeds.
+	AutoInactive bool
+	// DefaultTimeout is the time to wait for an app to settle when the
+	// deployment payload does not specify a timeout.
+	DefaultTimeout time.Duration
+	// DryRun only computes and reports the App CR changes of every
+	// deployment without applying them.
+	DryRun bool
+	Env    string
+	// EnvironmentURLTemplate is a text/template rendering the environment
+	// URL of deployment statuses from EnvironmentURLData. When empty no
+	// environment URL is reported.
+	EnvironmentURLTemplate string
+	// MaxTimeout bounds the timeout deployment payloads can specify.
+	MaxTimeout time.Duration
+	// Rollback restores the previous App CR spec when a deployment fails and
+	// the deployment payload does not specify otherwise.
+	Rollback bool
+	// WebhookBaseURL is the external URL of app-checker which deployment
+	// status log URLs point to.
+	WebhookBaseURL string
+}
+
+type Deployer struct {
+	appWatcher    *appwatcher.Watcher
+	catalogIndex  *index.Index
+	catalogRouter *catalog.Router
+	githubClient  *github.Client
+	history       *history.Recorder
+	k8sClient     k8sclient.Interface
+	logger        micrologger.Logger
+	reporter      *reporter.Reporter
+	tracker       *tracker.Tracker
+
+	locks *appLocks
+	// manualMutex serializes allocating IDs of manual deployments, the last
+	// of which is lastManualID.
+	manualMutex  sync.Mutex
+	lastManualID int64
+
+	autoInactive           bool
+	defaultTimeout         time.Duration
+	dryRun                 bool
+	env                    string
+	environmentURLTemplate *template.Template
+	maxTimeout             time.Duration
+	rollback               bool
+	webhookBaseURL         string
+}
+
+func New(config Config) (*Deployer, error) {
+	if config.AppWatcher == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.AppWatcher must not be empty", config)
+	}
+	if config.CatalogRouter == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CatalogRouter must not be empty", config)
+	}
+	if config.GithubClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GithubClient must not be empty", config)
+	}
+	if config.History == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.History must not be empty", config)
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Reporter == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Reporter must not be empty", config)
+	}
+	if config.Tracker == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Tracker must not be empty", config)
+	}
+
+	if config.DefaultTimeout <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.DefaultTimeout must be greater than zero", config)
+	}
+	if config.Env == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Env must not be empty", config)
+	}
+	if config.MaxTimeout < config.DefaultTimeout {
+		return nil, microerror.Maskf(invalidConfigError, "%T.MaxTimeout must not be less than %T.DefaultTimeout", config, config)
+	}
+
+	var environmentURLTemplate *template.Template
+	if config.EnvironmentURLTemplate != "" {
+		t, err := template.New("environmentURL").Option("missingkey=error").Parse(config.EnvironmentURLTemplate)
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "%T.EnvironmentURLTemplate: %s", config, err)
+		}
+
+		environmentURLTemplate = t
+	}
+
+	d := &Deployer{
+		appWatcher:    config.AppWatcher,
+		catalogIndex:  config.CatalogIndex,
+		catalogRouter: config.CatalogRouter,
+		githubClient:  config.GithubClient,
+		history:       config.History,
+		k8sClient:     config.K8sClient,
+		logger:        config.Logger,
+		reporter:      config.Reporter,
+		tracker:       config.Tracker,
+
+		locks: newAppLocks(),
+
+		autoInactive:           config.AutoInactive,
+		defaultTimeout:         config.DefaultTimeout,
+		dryRun:                 config.DryRun,
+		env:                    config.Env,
+		environmentURLTemplate: environmentURLTemplate,
+		maxTimeout:             config.MaxTimeout,
+		rollback:               config.Rollback,
+		webhookBaseURL:         strings.TrimSuffix(config.WebhookBaseURL, "/"),
+	}
+
+	return d, nil
+}
+
+func (d *Deployer) ProcessDeploymentEvent(ctx context.Context, event *github.DeploymentEvent) error {
+	start := time.Now()
+
+	// When authenticating as a GitHub App, report back through the
+	// installation which delivered the event.
+	if id := event.GetInstallation().GetID(); id != 0 {
+		ctx = githubapp.WithInstallationID(ctx, id)
+	}
+
+	if event.Deployment.GetTask() == TaskRemoveBranch {
+		err := d.removeBranch(ctx, event)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	remove := event.Deployment.GetTask() == TaskRemove
+
+	payload, err := ParseTaskPayload(event.Deployment.GetTask(), event.Deployment.Payload)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	dryRun := d.dryRun || payload.DryRun
+
+	appCRName := toAppCRName(event.Repo.GetName(), event.Deployment.GetRef(), payload)
+	appCRNamespace := toAppCRNamespace(payload)
+
+	var appCatalog string
+	var desiredAppCR *v1alpha1.App
+	if remove {
+		// Removals only need to locate the App CR.
+		desiredAppCR = &v1alpha1.App{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      appCRName,
+				Namespace: appCRNamespace,
+			},
+		}
+	} else {
+		var rawPayload map[string]interface{}
+		err = json.Unmarshal(event.Deployment.Payload, &rawPayload)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		input := catalog.Input{
+			AppVersion:  payload.AppVersion,
+			Environment: d.env,
+			Payload:     rawPayload,
+			Ref:         event.Deployment.GetRef(),
+			Repository:  event.Repo.GetName(),
+		}
+
+		appCatalog, err = d.catalogRouter.Catalog(input)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		appConfig := app.Config{
+			AppName:             *event.Repo.Name,
+			AppNamespace:        payload.Namespace,
+			AppCatalog:          appCatalog,
+			AppVersion:          payload.AppVersion,
+			DisableForceUpgrade: true,
+			Name:                appCRName,
+		}
+
+		if *event.Repo.Name == releases {
+			appConfig.AppName = payload.Chart
+		}
+
+		desiredAppCR = app.NewCR(appConfig)
+		desiredAppCR.Namespace = appCRNamespace
+		stampProvenance(desiredAppCR, event, payload, start)
+	}
+
+	{
+		deployment := tracker.Deployment{
+			ID:         event.Deployment.GetID(),
+			Owner:      event.Repo.GetOwner().GetLogin(),
+			Repository: event.Repo.GetName(),
+			Ref:        event.Deployment.GetRef(),
+			SHA:        event.Deployment.GetSHA(),
+
+			InstallationID: event.GetInstallation().GetID(),
+
+			App: tracker.App{
+				Catalog:   appCatalog,
+				Cluster:   payload.Cluster,
+				Name:      appCRName,
+				Namespace: appCRNamespace,
+				Version:   payload.AppVersion,
+			},
+			DryRun: dryRun,
+		}
+
+		d.tracker.Track(deployment)
+
+		// The history is informational, so failing to record it must not
+		// fail the deployment.
+		err = d.history.Start(ctx, deployment)
+		if err != nil {
+			d.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to record history of deployment %d", deployment.ID), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	// Dry runs do not touch the App CR, so they neither wait for nor
+	// supersede other deployments of it.
+	if dryRun {
+		return d.dryRunDeploy(ctx, event, payload, desiredAppCR, remove)
+	}
+
+	// Deployments of the same App CR are serialized. A newer deployment
+	// supersedes older ones waiting for the lock and cancels the one holding
+	// it.
+	key := appCRNamespace + "/" + appCRName
+	pos := positionOf(event)
+
+	lockCtx, release, supersededBy, err := d.locks.acquire(ctx, key, pos)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	if supersededBy != 0 {
+		return d.reportSuperseded(ctx, event, desiredAppCR, "inactive", supersededBy)
+	}
+	defer release()
+
+	if remove {
+		err = d.remove(lockCtx, event, payload, desiredAppCR)
+	} else {
+		err = d.deploy(lockCtx, event, payload, desiredAppCR, start)
+	}
+	if err != nil && ctx.Err() == nil {
+		supersededBy = d.locks.supersededBy(key, pos)
+		if supersededBy != 0 {
+			return d.reportSuperseded(ctx, event, desiredAppCR, "error", supersededBy)
+		}
+	}
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// deploy creates or updates the desired App CR of the given deployment and
+// reports the resulting app status. The caller must hold the lock of the App
+// CR.
+func (d *Deployer) deploy(ctx context.Context, event *github.DeploymentEvent, payload *Payload, desiredAppCR *v1alpha1.App, start time.Time) error {
+	appCRName := desiredAppCR.GetName()
+	appCRNamespace := desiredAppCR.GetNamespace()
+
+	timeout, err := d.timeout(payload)
+	if IsInvalidDeployment(err) {
+		return d.reportInvalid(ctx, event, desiredAppCR, err)
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = d.validateVersion(ctx, desiredAppCR)
+	if IsInvalidDeployment(err) {
+		return d.reportInvalid(ctx, event, desiredAppCR, err)
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if payload.Cluster != "" {
+		err = d.configureClusterApp(ctx, payload.Cluster, desiredAppCR)
+		if IsInvalidDeployment(err) {
+			return d.reportInvalid(ctx, event, desiredAppCR, err)
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	err = d.ensureUserConfig(ctx, payload, desiredAppCR, false)
+	if IsInvalidDeployment(err) {
+		return d.reportInvalid(ctx, event, desiredAppCR, err)
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var lastResourceVersion string
+	var created bool
+	// previousSpec is the spec the App CR had before this deployment updated
+	// it. It is only known when the App CR got updated by this very process.
+	var previousSpec *v1alpha1.AppSpec
+
+	// Find matching app CR.
+	currentApp, err := d.k8sClient.G8sClient().ApplicationV1alpha1().Apps(appCRNamespace).Get(ctx, appCRName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		created = true
+		newApp, err := d.k8sClient.G8sClient().ApplicationV1alpha1().Apps(appCRNamespace).Create(ctx, desiredAppCR, metav1.CreateOptions{})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		lastResourceVersion = newApp.GetResourceVersion()
+	} else if err != nil {
+		return microerror.Mask(err)
+	} else {
+		lastResourceVersion = currentApp.GetResourceVersion()
+	}
+
+	if !created && equals(currentApp, desiredAppCR) {
+		status := currentApp.Status.Release.Status
+
+		// if app is equal to the desired spec and its release is settled, no op.
+		if isFinal(status) {
+			d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deployed already app %#q with version %#q", appCRName, payload.AppVersion))
+
+			err = d.reportStatus(ctx, event, desiredAppCR, status, currentApp.Status.Release.Reason)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		}
+
+		// The app CR got updated already, e.g. by a deployment resumed
+		// after a restart, but app-operator did not settle its release
+		// yet. So we keep watching it.
+		d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("app %#q with version %#q has release status %#q, waiting for it to settle", appCRName, payload.AppVersion, status))
+	} else if !created {
+		previousSpec = currentApp.Spec.DeepCopy()
+		desiredAppCR.ObjectMeta.ResourceVersion = currentApp.GetResourceVersion()
+
+		// if app is not equal to the desired spec, update current app.
+		updateAppCR, err := d.k8sClient.G8sClient().ApplicationV1alpha1().Apps(appCRNamespace).Update(ctx, desiredAppCR, metav1.UpdateOptions{})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		lastResourceVersion = updateAppCR.GetResourceVersion()
+
+		// Without rollback the user config the App CR referenced before is
+		// not needed anymore, so it is not kept until the deployment
+		// finished.
+		if !d.shouldRollback(payload) {
+			err = d.cleanupUserConfig(ctx, appCRNamespace, appCRName)
+			if err != nil {
+				d.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to clean up user config of app %#q", appCRName), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+	}
+
+	d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deploying app %#q with version %#q", appCRName, payload.AppVersion))
+
+	sub := d.appWatcher.Subscribe(appCRNamespace, appCRName)
+	defer sub.Close()
+
+	// Waiting for status update.
+	// meanwhile, creating deployment status event.
+	err = d.reportStatus(ctx, event, desiredAppCR, "in_progress", "")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	status, reason, err := d.waitForRelease(ctx, event, desiredAppCR, sub, lastResourceVersion, timeout)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("app %#q with version %#q deployment status: %#q", appCRName, payload.AppVersion, status))
+
+	if status == "deployed" {
+		deploymentHistogram.WithLabelValues(desiredAppCR.Spec.Catalog).Observe(time.Since(start).Seconds())
+	} else if previousSpec != nil && d.shouldRollback(payload) {
+		outcome, err := d.restorePreviousSpec(ctx, event, desiredAppCR, *previousSpec, sub, timeout, reason)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		reason = fmt.Sprintf("%s after failure: %s", outcome, reason)
+	}
+
+	err = d.reportStatus(ctx, event, desiredAppCR, status, reason)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	countDeployment(desiredAppCR, status)
+
+	err = d.cleanupUserConfig(ctx, appCRNamespace, appCRName)
+	if err != nil {
+		d.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to clean up user config of app %#q", appCRName), "stack", fmt.Sprintf("%#v", err))
+	}
+
+	return nil
+}
+
+// reportSuperseded reports a deployment superseded by the newer deployment
+// with the given ID using the given GitHub deployment state.
+func (d *Deployer) reportSuperseded(ctx context.Context, event *github.DeploymentEvent, desiredAppCR *v1alpha1.App, state string, supersededBy int64) error {
+	d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deployment %d of app %#q superseded by deployment %d", event.Deployment.GetID(), desiredAppCR.GetName(), supersededBy))
+
+	err := d.updateGithubDeploymentStatus(ctx, event, desiredAppCR, state, fmt.Sprintf("superseded by #%d", supersededBy))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// reportInvalid reports a deployment which cannot be deployed as requested as
+// failed.
+func (d *Deployer) reportInvalid(ctx context.Context, event *github.DeploymentEvent, desiredAppCR *v1alpha1.App, invalidErr error) error {
+	reason := microerror.Pretty(invalidErr, false)
+
+	d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("cannot deploy app %#q: %s", desiredAppCR.GetName(), reason))
+
+	err := d.reportStatus(ctx, event, desiredAppCR, "failed", reason)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	countDeployment(desiredAppCR, "failed")
+
+	return nil
+}
+
+// waitForRelease waits for app-operator to settle the release of the App CR
+// in a resource version newer than lastResourceVersion. Intermediate release
+// statuses are reported as pending. The final release status and reason are
+// returned, which is `failed` when the timeout expires.
+func (d *Deployer) waitForRelease(ctx context.Context, event *github.DeploymentEvent, desiredAppCR *v1alpha1.App, sub *appwatcher.Subscription, lastResourceVersion string, timeout time.Duration) (string, string, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case cr := <-sub.Updates():
+			if !isNewer(cr.GetResourceVersion(), lastResourceVersion) {
+				// no-op
+				d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("no need to reconcile for the older resourceVersion %s", cr.GetResourceVersion()))
+				continue
+			}
+
+			status := cr.Status.Release.Status
+			if isFinal(status) {
+				return status, cr.Status.Release.Reason, nil
+			}
+
+			err := d.reportStatus(ctx, event, desiredAppCR, status, cr.Status.Release.Reason)
+			if err != nil {
+				return "", "", microerror.Mask(err)
+			}
+
+			lastResourceVersion = cr.GetResourceVersion()
+
+		case <-timer.C:
+			return "failed", fmt.Sprintf("deployment took longer than %s. check app-operator logs", timeout), nil
+
+		case <-ctx.Done():
+			return "", "", microerror.Mask(ctx.Err())
+		}
+	}
+}
+
+// restorePreviousSpec restores the given previous spec of the App CR and waits for it to
+// get deployed again. It returns a description of the outcome.
+func (d *Deployer) restorePreviousSpec(ctx context.Context, event *github.DeploymentEvent, desiredAppCR *v1alpha1.App, previousSpec v1alpha1.AppSpec, sub *appwatcher.Subscription, timeout time.Duration, reason string) (string, error) {
+	name := desiredAppCR.GetName()
+	namespace := desiredAppCR.GetNamespace()
+
+	d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("rolling back app %#q to version %#q", name, previousSpec.Version))
+
+	err := d.reportStatus(ctx, event, desiredAppCR, "in_progress", fmt.Sprintf("rolling back to version %s after failure: %s", previousSpec.Version, reason))
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	currentApp, err := d.k8sClient.G8sClient().ApplicationV1alpha1().Apps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	currentApp.Spec = previousSpec
+
+	updatedApp, err := d.k8sClient.G8sClient().ApplicationV1alpha1().Apps(namespace).Update(ctx, currentApp, metav1.UpdateOptions{})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	status, rollbackReason, err := d.waitForRelease(ctx, event, desiredAppCR, sub, updatedApp.GetResourceVersion(), timeout)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	if status != "deployed" {
+		d.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("rollback of app %#q to version %#q failed: %s", name, previousSpec.Version, rollbackReason))
+		return fmt.Sprintf("rollback to version %s failed (%s)", previousSpec.Version, rollbackReason), nil
+	}
+
+	d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("rolled back app %#q to version %#q", name, previousSpec.Version))
+
+	return fmt.Sprintf("rolled back to version %s", previousSpec.Version), nil
+}
+
+// shouldRollback returns whether the previous App CR spec is restored when
+// the given deployment fails.
+func (d *Deployer) shouldRollback(payload *Payload) bool {
+	if payload.Rollback != nil {
+		return *payload.Rollback
+	}
+
+	return d.rollback
+}
+
+// validateVersion checks that the version of the desired App CR is published
+// in its catalog. Failures to fetch the catalog index do not block the
+// deployment, app-operator reports them anyway.
+func (d *Deployer) validateVersion(ctx context.Context, desiredAppCR *v1alpha1.App) error {
+	if d.catalogIndex == nil {
+		return nil
+	}
+
+	err := d.catalogIndex.Validate(ctx, desiredAppCR.Spec.Catalog, desiredAppCR.Spec.Name, desiredAppCR.Spec.Version)
+	if index.IsCatalogNotFound(err) {
+		return microerror.Maskf(invalidDeploymentError, "catalog %#q not found", desiredAppCR.Spec.Catalog)
+	} else if index.IsVersionNotFound(err) {
+		return microerror.Maskf(invalidDeploymentError, "version %#q of app %#q not found in catalog %#q", desiredAppCR.Spec.Version, desiredAppCR.Spec.Name, desiredAppCR.Spec.Catalog)
+	} else if ctx.Err() != nil {
+		return microerror.Mask(ctx.Err())
+	} else if err != nil {
+		d.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to validate version %#q of app %#q", desiredAppCR.Spec.Version, desiredAppCR.Spec.Name), "stack", fmt.Sprintf("%#v", err))
+		return nil
+	}
+
+	return nil
+}
+
+// timeout returns the time to wait for the app of the given deployment to
+// settle.
+func (d *Deployer) timeout(payload *Payload) (time.Duration, error) {
+	if payload.Timeout == "" {
+		return d.defaultTimeout, nil
+	}
+
+	// ParsePayload validated the timeout already.
+	timeout, _ := time.ParseDuration(payload.Timeout)
+	if timeout > d.maxTimeout {
+		return 0, microerror.Maskf(invalidDeploymentError, "timeout %s exceeds maximum of %s", timeout, d.maxTimeout)
+	}
+
+	return timeout, nil
+}
+
+// toAppCRName returns the name of the App CR the given deployment is deployed
+// to. Unique deployments share one App CR, all others get one per ref.
+func toAppCRName(repository, ref string, payload *Payload) string {
+	prefixName := repository
+	if repository == releases {
+		prefixName = payload.Chart
+	}
+
+	if payload.Unique {
+		return fmt.Sprintf("%s-%s", prefixName, "unique")
+	}
+
+	return fmt.Sprintf("%s-%s", prefixName, ref)
+}
+
+// ParsePayload decodes and validates the deployer specific payload of a
+// GitHub deployment.
+func ParsePayload(rawPayload []byte) (*Payload, error) {
+	var e Payload
+
+	err := json.Unmarshal(rawPayload, &e)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if e.AppVersion == "" {
+		return nil, microerror.Maskf(decodeFailedError, "not found field `appVersion` in payload")
+	}
+	if e.Namespace == "" {
+		return nil, microerror.Maskf(decodeFailedError, "not found field `namespace` in payload")
+	}
+	if e.UserConfig != nil {
+		if e.UserConfig.ConfigMap != nil && e.UserConfig.ConfigMap.Name == "" {
+			return nil, microerror.Maskf(decodeFailedError, "not found field `userConfig.configMap.name` in payload")
+		}
+		if e.UserConfig.Secret != nil && e.UserConfig.Secret.Name == "" {
+			return nil, microerror.Maskf(decodeFailedError, "not found field `userConfig.secret.name` in payload")
+		}
+	}
+	if e.Cluster != "" && len(validation.IsDNS1123Label(e.Cluster)) > 0 {
+		return nil, microerror.Maskf(decodeFailedError, "field `cluster` in payload must be a valid cluster ID")
+	}
+	if e.Timeout != "" {
+		timeout, err := time.ParseDuration(e.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, microerror.Maskf(decodeFailedError, "field `timeout` in payload must be a positive duration, e.g. `5m`")
+		}
+	}
+
+	return &e, nil
+}
+
+// ParseRemovePayload decodes and validates the deployer specific payload of a
+// GitHub deployment with the `remove` task. Only the fields locating the App
+// CR are required.
+func ParseRemovePayload(rawPayload []byte) (*Payload, error) {
+	var e Payload
+
+	err := json.Unmarshal(rawPayload, &e)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if e.Namespace == "" && e.Cluster == "" {
+		return nil, microerror.Maskf(decodeFailedError, "not found field `namespace` in payload")
+	}
+	if e.Cluster != "" && len(validation.IsDNS1123Label(e.Cluster)) > 0 {
+		return nil, microerror.Maskf(decodeFailedError, "field `cluster` in payload must be a valid cluster ID")
+	}
+	if e.Timeout != "" {
+		timeout, err := time.ParseDuration(e.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, microerror.Maskf(decodeFailedError, "field `timeout` in payload must be a positive duration, e.g. `5m`")
+		}
+	}
+
+	return &e, nil
+}
+
+// ParseTaskPayload parses the payload of a GitHub deployment according to its
+// task.
+func ParseTaskPayload(task string, rawPayload []byte) (*Payload, error) {
+	if task == TaskRemove {
+		return ParseRemovePayload(rawPayload)
+	}
+
+	return ParsePayload(rawPayload)
+}
+
+// equals asseses the equality of ReleaseStates with regards to distinguishing fields.
+// User config changes are covered by the spec, as the names of managed user
+// config ConfigMaps and Secrets are derived from their content. Provenance
+// annotations differ for every deployment and are ignored, so re-deliveries
+// stay no-ops.
+func equals(current, desired *v1alpha1.App) bool {
+	if current.Name != desired.Name {
+		return false
+	}
+	if !reflect.DeepEqual(current.Spec, desired.Spec) {
+		return false
+	}
+	if !reflect.DeepEqual(current.Labels, desired.Labels) {
+		return false
+	}
+	if !reflect.DeepEqual(withoutProvenance(current.Annotations), withoutProvenance(desired.Annotations)) {
+		return false
+	}
+
+	return true
+}
+
+// isFinal returns whether the given app release status is one app-operator
+// does not transition away from without a spec change.
+func isFinal(status string) bool {
+	return status == "deployed" || status == "not-installed" || status == "failed"
+}
+
+// isNewer returns whether resourceVersion is newer than last. Resource
+// versions are opaque, but in practice backed by etcd revisions. When they
+// cannot be parsed as such, any different version is considered newer.
+func isNewer(resourceVersion, last string) bool {
+	r, err := strconv.ParseUint(resourceVersion, 10, 64)
+	if err != nil {
+		return resourceVersion != last
+	}
+	l, err := strconv.ParseUint(last, 10, 64)
+	if err != nil {
+		return resourceVersion != last
+	}
+
+	return r > l
+}
@@ -0,0 +1,194 @@
+package deployer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/apiextensions/v3/pkg/apis/application/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/google/go-github/v32/github"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// dryRunDeploy computes the changes the given deployment would make to its
+// App CR without applying them. The changes are logged, kept with the tracked
+// deployment and summarized as inactive GitHub deployment status, as nothing
+// got deployed.
+func (d *Deployer) dryRunDeploy(ctx context.Context, event *github.DeploymentEvent, payload *Payload, desiredAppCR *v1alpha1.App, remove bool) error {
+	name := desiredAppCR.GetName()
+
+	var current *v1alpha1.App
+	{
+		cr, err := d.k8sClient.G8sClient().ApplicationV1alpha1().Apps(desiredAppCR.GetNamespace()).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		} else {
+			current = cr
+		}
+	}
+
+	var diff []string
+	var summary string
+	if remove {
+		if current == nil {
+			summary = fmt.Sprintf("app %s does not exist", name)
+		} else if !isManaged(current) {
+			summary = fmt.Sprintf("would not remove app %s, it is not managed by app-checker", name)
+		} else {
+			summary = fmt.Sprintf("would remove app %s", name)
+		}
+	} else {
+		err := d.dryRunDesired(ctx, payload, desiredAppCR)
+		if IsInvalidDeployment(err) {
+			reason := microerror.Pretty(err, false)
+
+			d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("dry run cannot deploy app %#q: %s", name, reason))
+
+			err = d.updateGithubDeploymentStatus(ctx, event, desiredAppCR, "failure", fmt.Sprintf("dry run, %s", reason))
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			return nil
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		diff = diffApps(current, desiredAppCR)
+
+		if current == nil {
+			summary = fmt.Sprintf("would create app %s", name)
+		} else if len(diff) == 0 {
+			summary = fmt.Sprintf("app %s is up to date", name)
+		} else {
+			summary = fmt.Sprintf("would update app %s: %s", name, strings.Join(diff, ", "))
+		}
+	}
+
+	d.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("dry run of deployment %d: %s", event.Deployment.GetID(), summary), "diff", strings.Join(diff, "\n"))
+
+	d.tracker.SetDiff(event.Deployment.GetID(), diff)
+
+	err := d.updateGithubDeploymentStatus(ctx, event, desiredAppCR, "inactive", fmt.Sprintf("dry run, %s", summary))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// dryRunDesired completes the desired App CR like a deployment would, without
+// creating any resources.
+func (d *Deployer) dryRunDesired(ctx context.Context, payload *Payload, desiredAppCR *v1alpha1.App) error {
+	_, err := d.timeout(payload)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = d.validateVersion(ctx, desiredAppCR)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if payload.Cluster != "" {
+		err = d.configureClusterApp(ctx, payload.Cluster, desiredAppCR)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	err = d.ensureUserConfig(ctx, payload, desiredAppCR, true)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// diffApps returns the changes from the current to the desired App CR as
+// `<field>: <current> -> <desired>`, sorted by field. It covers the fields
+// equals compares. A nil current App CR lists every desired field.
+func diffApps(current, desired *v1alpha1.App) []string {
+	from := map[string]string{}
+	if current != nil {
+		flatten(from, "", diffFields(current))
+	}
+
+	to := map[string]string{}
+	flatten(to, "", diffFields(desired))
+
+	var diff []string
+	for k, v := range to {
+		if from[k] != v {
+			diff = append(diff, fmt.Sprintf("%s: %s -> %s", k, orNone(from[k]), v))
+		}
+	}
+	for k, v := range from {
+		if _, ok := to[k]; !ok {
+			diff = append(diff, fmt.Sprintf("%s: %s -> <none>", k, v))
+		}
+	}
+
+	sort.Strings(diff)
+
+	return diff
+}
+
+// diffFields returns the fields of the given App CR which are compared by
+// equals as generic JSON value.
+func diffFields(cr *v1alpha1.App) interface{} {
+	fields := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": withoutProvenance(cr.Annotations),
+			"labels":      cr.Labels,
+		},
+		"spec": cr.Spec,
+	}
+
+	// The fields are built from a typed App CR, so they always marshal.
+	b, _ := json.Marshal(fields)
+
+	var v interface{}
+	_ = json.Unmarshal(b, &v)
+
+	return v
+}
+
+// flatten adds the leaves of the given JSON value to fields, keyed by their
+// dotted path.
+func flatten(fields map[string]string, path string, v interface{}) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		if s, ok := v.(string); v == nil || ok && s == "" {
+			return
+		}
+
+		b, _ := json.Marshal(v)
+		fields[path] = string(b)
+
+		return
+	}
+
+	for k, child := range m {
+		p := k
+		if path != "" {
+			p = path + "." + k
+		}
+
+		flatten(fields, p, child)
+	}
+}
+
+func orNone(v string) string {
+	if v == "" {
+		return "<none>"
+	}
+
+	return v
+}
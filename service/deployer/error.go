@@ -0,0 +1,50 @@
+package deployer
+
+import "github.com/giantswarm/microerror"
+
+var decodeFailedError = &microerror.Error{
+	Kind: "decodeFailedError",
+}
+
+// IsDecodeFailed asserts decodeFailedError.
+func IsDecodeFailed(err error) bool {
+	return microerror.Cause(err) == decodeFailedError
+}
+
+var executionFailedError = &microerror.Error{
+	Kind: "executionFailedError",
+}
+
+// IsExecutionFailed asserts executionFailedError.
+func IsExecutionFailed(err error) bool {
+	return microerror.Cause(err) == executionFailedError
+}
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+// invalidDeploymentError is returned for deployments which cannot be deployed
+// as requested. Its annotation is reported as GitHub deployment failure.
+var invalidDeploymentError = &microerror.Error{
+	Kind: "invalidDeploymentError",
+}
+
+// IsInvalidDeployment asserts invalidDeploymentError.
+func IsInvalidDeployment(err error) bool {
+	return microerror.Cause(err) == invalidDeploymentError
+}
+
+var notFoundError = &microerror.Error{
+	Kind: "notFoundError",
+}
+
+// IsNotFound asserts notFoundError.
+func IsNotFound(err error) bool {
+	return microerror.Cause(err) == notFoundError
+}
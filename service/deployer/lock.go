@@ -0,0 +1,194 @@
+package deployer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+)
+
+const (
+	// lockRetention is the time an idle lock is kept after its last release,
+	// so that older deployments delivered late are superseded as well.
+	lockRetention = time.Hour
+	// lockPruneInterval is the minimum interval between pruning idle locks.
+	lockPruneInterval = 10 * time.Minute
+)
+
+// appLocks serializes the deployments of each App CR. A newer deployment
+// supersedes older ones waiting for or holding the lock of the same App CR.
+// Locks nobody holds or waits for are dropped after lockRetention, so only
+// App CRs deployed recently take up memory.
+type appLocks struct {
+	mutex     sync.Mutex
+	locks     map[string]*appLock
+	lastPrune time.Time
+}
+
+type appLock struct {
+	// cancel cancels the context of the deployment holding the lock.
+	cancel context.CancelFunc
+	// holder is the ID of the deployment holding the lock, or zero.
+	holder int64
+	// latest is the position of the newest deployment waiting for or holding
+	// the lock.
+	latest position
+	// released is closed once the holder releases the lock.
+	released chan struct{}
+	// releasedAt is the time the lock was released last.
+	releasedAt time.Time
+	// waiters is the number of deployments waiting for the lock.
+	waiters int
+}
+
+// position orders the deployments of an App CR. GitHub deployment IDs grow
+// monotonically. Manual deployments without GitHub deployment use negative
+// IDs which shrink monotonically. Deployments of different ID spaces are
+// ordered by their creation time.
+type position struct {
+	created time.Time
+	id      int64
+}
+
+// after returns whether p is newer than o. The zero position is older than
+// any deployment.
+func (p position) after(o position) bool {
+	switch {
+	case o.id == 0:
+		return p.id != 0
+	case p.id > 0 && o.id > 0:
+		return p.id > o.id
+	case p.id < 0 && o.id < 0:
+		return p.id < o.id
+	default:
+		return p.created.After(o.created)
+	}
+}
+
+// positionOf returns the position of the given deployment event.
+func positionOf(event *github.DeploymentEvent) position {
+	p := position{
+		created: event.Deployment.GetCreatedAt().Time,
+		id:      event.Deployment.GetID(),
+	}
+
+	return p
+}
+
+func newAppLocks() *appLocks {
+	return &appLocks{
+		locks:     map[string]*appLock{},
+		lastPrune: time.Now(),
+	}
+}
+
+// acquire blocks until the deployment at the given position holds the lock of
+// the given App CR key. The returned context is cancelled once a newer deployment
+// supersedes the holder, and release must be called when the deployment is
+// done. When a newer deployment superseded the given one before it got the
+// lock, the ID of the newer deployment is returned instead.
+func (l *appLocks) acquire(ctx context.Context, key string, p position) (context.Context, func(), int64, error) {
+	id := p.id
+
+	for {
+		l.mutex.Lock()
+
+		lock, ok := l.locks[key]
+		if !ok {
+			lock = &appLock{}
+			l.locks[key] = lock
+		}
+
+		if lock.latest.after(p) {
+			supersededBy := lock.latest.id
+			l.mutex.Unlock()
+
+			return nil, nil, supersededBy, nil
+		}
+		lock.latest = p
+
+		if lock.holder == 0 {
+			lockCtx, cancel := context.WithCancel(ctx)
+
+			lock.cancel = cancel
+			lock.holder = id
+			lock.released = make(chan struct{})
+			l.mutex.Unlock()
+
+			release := func() {
+				l.release(key, id)
+			}
+
+			return lockCtx, release, 0, nil
+		}
+
+		if lock.holder != id {
+			lock.cancel()
+		}
+
+		released := lock.released
+		lock.waiters++
+		l.mutex.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+		}
+
+		l.mutex.Lock()
+		lock.waiters--
+		lock.releasedAt = time.Now()
+		l.mutex.Unlock()
+
+		if ctx.Err() != nil {
+			return nil, nil, 0, ctx.Err()
+		}
+	}
+}
+
+// supersededBy returns the ID of the deployment which superseded the
+// deployment at the given position of the given App CR key, or zero.
+func (l *appLocks) supersededBy(key string, p position) int64 {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	lock, ok := l.locks[key]
+	if !ok || !lock.latest.after(p) {
+		return 0
+	}
+
+	return lock.latest.id
+}
+
+func (l *appLocks) release(key string, id int64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	lock, ok := l.locks[key]
+	if !ok || lock.holder != id {
+		return
+	}
+
+	lock.cancel()
+	lock.holder = 0
+	lock.releasedAt = time.Now()
+	close(lock.released)
+
+	l.prune()
+}
+
+// prune drops the locks nobody held or waited for within lockRetention. The
+// caller must hold the mutex.
+func (l *appLocks) prune() {
+	if time.Since(l.lastPrune) < lockPruneInterval {
+		return
+	}
+	l.lastPrune = time.Now()
+
+	for key, lock := range l.locks {
+		if lock.holder == 0 && lock.waiters == 0 && time.Since(lock.releasedAt) > lockRetention {
+			delete(l.locks, key)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package deployer
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+)
+
+func Test_position_after(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name     string
+		p        position
+		o        position
+		expected bool
+	}{
+		{
+			name:     "case 0: newer GitHub deployment",
+			p:        position{created: now, id: 2},
+			o:        position{created: now, id: 1},
+			expected: true,
+		},
+		{
+			name:     "case 1: older GitHub deployment",
+			p:        position{created: now, id: 1},
+			o:        position{created: now, id: 2},
+			expected: false,
+		},
+		{
+			name:     "case 2: same deployment",
+			p:        position{created: now, id: 1},
+			o:        position{created: now, id: 1},
+			expected: false,
+		},
+		{
+			name:     "case 3: newer manual deployment",
+			p:        position{created: now, id: -2},
+			o:        position{created: now, id: -1},
+			expected: true,
+		},
+		{
+			name:     "case 4: older manual deployment",
+			p:        position{created: now, id: -1},
+			o:        position{created: now, id: -2},
+			expected: false,
+		},
+		{
+			name:     "case 5: manual deployment created after GitHub deployment",
+			p:        position{created: now, id: -1},
+			o:        position{created: now.Add(-time.Minute), id: 100},
+			expected: true,
+		},
+		{
+			name:     "case 6: GitHub deployment created before manual deployment",
+			p:        position{created: now.Add(-time.Minute), id: 100},
+			o:        position{created: now, id: -1},
+			expected: false,
+		},
+		{
+			name:     "case 7: any deployment after the zero position",
+			p:        position{created: now, id: 1},
+			o:        position{},
+			expected: true,
+		},
+		{
+			name:     "case 8: zero position after the zero position",
+			p:        position{},
+			o:        position{},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			after := tc.p.after(tc.o)
+			if after != tc.expected {
+				t.Fatalf("after == %t, want %t", after, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_appLocks_acquire(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name string
+		// holder is the position of the deployment holding the lock, if any.
+		holder *position
+		p      position
+		// expectedSupersededBy is the ID of the deployment superseding the
+		// acquiring one.
+		expectedSupersededBy int64
+		// expectedCancelled is whether the context of the holder gets
+		// cancelled.
+		expectedCancelled bool
+	}{
+		{
+			name: "case 0: free lock acquired",
+			p:    position{created: now, id: 1},
+		},
+		{
+			name:                 "case 1: older deployment superseded by holder",
+			holder:               &position{created: now, id: 2},
+			p:                    position{created: now, id: 1},
+			expectedSupersededBy: 2,
+		},
+		{
+			name:              "case 2: newer deployment cancels holder and waits for the lock",
+			holder:            &position{created: now, id: 1},
+			p:                 position{created: now, id: 2},
+			expectedCancelled: true,
+		},
+		{
+			name:              "case 3: newer manual deployment cancels holder and waits for the lock",
+			holder:            &position{created: now.Add(-time.Minute), id: 100},
+			p:                 position{created: now, id: -1},
+			expectedCancelled: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := newAppLocks()
+
+			var holderCtx context.Context
+			var releaseHolder func()
+			if tc.holder != nil {
+				var err error
+				holderCtx, releaseHolder, _, err = l.acquire(context.Background(), "giantswarm/app", *tc.holder)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			type result struct {
+				release      func()
+				supersededBy int64
+				err          error
+			}
+
+			results := make(chan result, 1)
+			go func() {
+				_, release, supersededBy, err := l.acquire(context.Background(), "giantswarm/app", tc.p)
+				results <- result{release: release, supersededBy: supersededBy, err: err}
+			}()
+
+			if tc.expectedCancelled {
+				select {
+				case <-holderCtx.Done():
+				case <-time.After(time.Second):
+					t.Fatalf("holder context not cancelled")
+				}
+
+				select {
+				case <-results:
+					t.Fatalf("lock acquired before the holder released it")
+				case <-time.After(10 * time.Millisecond):
+				}
+
+				releaseHolder()
+			}
+
+			var r result
+			select {
+			case r = <-results:
+			case <-time.After(time.Second):
+				t.Fatalf("acquire did not return")
+			}
+
+			if r.err != nil {
+				t.Fatal(r.err)
+			}
+			if r.supersededBy != tc.expectedSupersededBy {
+				t.Fatalf("supersededBy == %d, want %d", r.supersededBy, tc.expectedSupersededBy)
+			}
+			if r.supersededBy == 0 && r.release == nil {
+				t.Fatalf("release == nil, want non-nil")
+			}
+			if tc.holder != nil && !tc.expectedCancelled && holderCtx.Err() != nil {
+				t.Fatalf("holder context cancelled")
+			}
+		})
+	}
+}
+
+func Test_appLocks_supersededBy(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name string
+		// acquired are the positions of the deployments which acquired the
+		// lock in order, releasing it right away.
+		acquired []position
+		p        position
+		expected int64
+	}{
+		{
+			name:     "case 0: unknown App CR",
+			p:        position{created: now, id: 1},
+			expected: 0,
+		},
+		{
+			name:     "case 1: latest deployment",
+			acquired: []position{{created: now, id: 1}, {created: now, id: 2}},
+			p:        position{created: now, id: 2},
+			expected: 0,
+		},
+		{
+			name:     "case 2: older deployment delivered late",
+			acquired: []position{{created: now, id: 1}, {created: now, id: 3}},
+			p:        position{created: now, id: 2},
+			expected: 3,
+		},
+		{
+			name:     "case 3: GitHub deployment created before manual deployment",
+			acquired: []position{{created: now, id: -1}},
+			p:        position{created: now.Add(-time.Minute), id: 100},
+			expected: -1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := newAppLocks()
+
+			for _, p := range tc.acquired {
+				_, release, _, err := l.acquire(context.Background(), "giantswarm/app", p)
+				if err != nil {
+					t.Fatal(err)
+				}
+				release()
+			}
+
+			supersededBy := l.supersededBy("giantswarm/app", tc.p)
+			if supersededBy != tc.expected {
+				t.Fatalf("supersededBy == %d, want %d", supersededBy, tc.expected)
+			}
+		})
+	}
+}
+
+func Test_appLocks_prune(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name      string
+		locks     map[string]*appLock
+		lastPrune time.Time
+		expected  []string
+	}{
+		{
+			name: "case 0: idle lock past retention pruned",
+			locks: map[string]*appLock{
+				"giantswarm/idle":   {releasedAt: now.Add(-2 * lockRetention)},
+				"giantswarm/recent": {releasedAt: now.Add(-time.Minute)},
+			},
+			lastPrune: now.Add(-2 * lockPruneInterval),
+			expected:  []string{"giantswarm/recent"},
+		},
+		{
+			name: "case 1: held and waited for locks kept",
+			locks: map[string]*appLock{
+				"giantswarm/held":   {holder: 1, releasedAt: now.Add(-2 * lockRetention)},
+				"giantswarm/waited": {waiters: 1, releasedAt: now.Add(-2 * lockRetention)},
+			},
+			lastPrune: now.Add(-2 * lockPruneInterval),
+			expected:  []string{"giantswarm/held", "giantswarm/waited"},
+		},
+		{
+			name: "case 2: not pruned within the prune interval",
+			locks: map[string]*appLock{
+				"giantswarm/idle": {releasedAt: now.Add(-2 * lockRetention)},
+			},
+			lastPrune: now.Add(-time.Minute),
+			expected:  []string{"giantswarm/idle"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := &appLocks{
+				locks:     tc.locks,
+				lastPrune: tc.lastPrune,
+			}
+
+			l.mutex.Lock()
+			l.prune()
+			l.mutex.Unlock()
+
+			var keys []string
+			for key := range l.locks {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			if len(keys) != len(tc.expected) {
+				t.Fatalf("locks == %v, want %v", keys, tc.expected)
+			}
+			for i := range keys {
+				if keys[i] != tc.expected[i] {
+					t.Fatalf("locks == %v, want %v", keys, tc.expected)
+				}
+			}
+		})
+	}
+}
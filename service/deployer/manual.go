@@ -0,0 +1,120 @@
+package deployer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/google/go-github/v32/github"
+
+	"github.com/giantswarm/app-checker/pkg/githubapp"
+)
+
+const (
+	// TaskManual is the task of manual deployments without GitHub deployment.
+	// Their statuses are only recorded by app-checker and not reported to
+	// GitHub.
+	TaskManual = "deploy:manual"
+)
+
+// ManualEvent returns a deployment event for the given manual deployment. It
+// runs through the same pipeline as deployment events delivered by GitHub.
+// When requested, a GitHub deployment is created first so the deployment
+// history on GitHub stays consistent.
+func (d *Deployer) ManualEvent(ctx context.Context, m ManualDeployment) (*github.DeploymentEvent, error) {
+	_, err := ParsePayload(m.Payload)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var installation *github.Installation
+	if m.InstallationID != 0 {
+		ctx = githubapp.WithInstallationID(ctx, m.InstallationID)
+		installation = &github.Installation{ID: &m.InstallationID}
+	}
+
+	var deployment *github.Deployment
+	if m.CreateDeployment {
+		deployment, err = d.createDeployment(ctx, m)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	} else {
+		id := d.nextManualID()
+		task := TaskManual
+		deployment = &github.Deployment{
+			ID:          &id,
+			CreatedAt:   &github.Timestamp{Time: time.Now()},
+			Environment: &d.env,
+			Payload:     json.RawMessage(m.Payload),
+			Ref:         &m.Ref,
+			SHA:         &m.SHA,
+			Task:        &task,
+		}
+	}
+
+	e := &github.DeploymentEvent{
+		Deployment: deployment,
+		Repo: &github.Repository{
+			FullName: github.String(m.Owner + "/" + m.Repository),
+			Name:     github.String(m.Repository),
+			Owner: &github.User{
+				Login: github.String(m.Owner),
+			},
+		},
+		Installation: installation,
+	}
+
+	return e, nil
+}
+
+// createDeployment creates the GitHub deployment of the given manual
+// deployment. Commit statuses are not required, as manual deployments are
+// meant for when GitHub is partially unavailable.
+func (d *Deployer) createDeployment(ctx context.Context, m ManualDeployment) (*github.Deployment, error) {
+	request := &github.DeploymentRequest{
+		AutoMerge:        github.Bool(false),
+		Description:      github.String("manual deployment through app-checker"),
+		Environment:      &d.env,
+		Payload:          json.RawMessage(m.Payload),
+		Ref:              &m.Ref,
+		RequiredContexts: &[]string{},
+		Task:             github.String("deploy"),
+	}
+
+	deployment, res, err := d.githubClient.Repositories.CreateDeployment(ctx, m.Owner, m.Repository, request)
+	if res != nil && (res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusUnprocessableEntity) {
+		return nil, microerror.Maskf(notFoundError, "ref %#q does not exist in repository %s/%s", m.Ref, m.Owner, m.Repository)
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return deployment, nil
+}
+
+// nextManualID allocates the ID of a manual deployment without GitHub
+// deployment. Manual IDs are negative so they never collide with GitHub
+// deployment IDs. They are derived from the current time, so IDs allocated
+// later, also after a restart, are smaller and order manual deployments like
+// GitHub deployment IDs do.
+func (d *Deployer) nextManualID() int64 {
+	d.manualMutex.Lock()
+	defer d.manualMutex.Unlock()
+
+	id := -time.Now().UnixNano() / int64(time.Microsecond)
+	if d.lastManualID != 0 && id >= d.lastManualID {
+		id = d.lastManualID - 1
+	}
+
+	d.lastManualID = id
+
+	return id
+}
+
+// isManual returns whether the given event is a manual deployment without
+// GitHub deployment.
+func isManual(event *github.DeploymentEvent) bool {
+	return event.Deployment.GetTask() == TaskManual
+}
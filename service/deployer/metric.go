@@ -0,0 +1,36 @@
+package deployer
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	PrometheusNamespace = "app_checker"
+	PrometheusSubsystem = "deployer"
+)
+
+var (
+	deploymentCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: PrometheusNamespace,
+			Subsystem: PrometheusSubsystem,
+			Name:      "deployments_total",
+			Help:      "Number of rolled out or rejected deployments by final GitHub deployment status.",
+		},
+		[]string{"catalog", "status"},
+	)
+
+	deploymentHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: PrometheusNamespace,
+			Subsystem: PrometheusSubsystem,
+			Name:      "time_to_deployed_seconds",
+			Help:      "Time taken from processing a deployment event until its app got deployed.",
+			Buckets:   prometheus.ExponentialBuckets(5, 2, 10),
+		},
+		[]string{"catalog"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(deploymentCounter)
+	prometheus.MustRegister(deploymentHistogram)
+}
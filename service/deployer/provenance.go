@@ -0,0 +1,80 @@
+package deployer
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v3/pkg/apis/application/v1alpha1"
+	"github.com/google/go-github/v32/github"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/giantswarm/app-checker/pkg/annotation"
+	"github.com/giantswarm/app-checker/pkg/label"
+)
+
+// provenanceAnnotations describe the deployment which last created or updated
+// an App CR. They do not affect the deployed app.
+var provenanceAnnotations = []string{
+	annotation.Creator,
+	annotation.DeployedAt,
+	annotation.DeploymentID,
+	annotation.InstallationID,
+	annotation.Ref,
+	annotation.Repository,
+	annotation.SHA,
+}
+
+// stampProvenance labels and annotates the desired App CR with the origin of
+// the given deployment. The origin also allows garbage collecting the App CR
+// once its ref is gone.
+func stampProvenance(cr *v1alpha1.App, event *github.DeploymentEvent, payload *Payload, now time.Time) {
+	repository := event.Repo.GetName()
+
+	if cr.Labels == nil {
+		cr.Labels = map[string]string{}
+	}
+	cr.Labels[label.ManagedBy] = label.ManagedByValue()
+	if len(validation.IsValidLabelValue(repository)) == 0 {
+		cr.Labels[label.Repository] = repository
+	}
+
+	if cr.Annotations == nil {
+		cr.Annotations = map[string]string{}
+	}
+	cr.Annotations[annotation.DeployedAt] = now.UTC().Format(time.RFC3339)
+	cr.Annotations[annotation.DeploymentID] = strconv.FormatInt(event.Deployment.GetID(), 10)
+	cr.Annotations[annotation.Repository] = fmt.Sprintf("%s/%s", event.Repo.GetOwner().GetLogin(), repository)
+	cr.Annotations[annotation.SHA] = event.Deployment.GetSHA()
+
+	if creator := event.Deployment.GetCreator().GetLogin(); creator != "" {
+		cr.Annotations[annotation.Creator] = creator
+	}
+	if id := event.GetInstallation().GetID(); id != 0 {
+		cr.Annotations[annotation.InstallationID] = strconv.FormatInt(id, 10)
+	}
+	if !payload.Unique {
+		cr.Annotations[annotation.Ref] = event.Deployment.GetRef()
+	}
+}
+
+// withoutProvenance returns a copy of the given annotations without the
+// provenance annotations.
+func withoutProvenance(annotations map[string]string) map[string]string {
+	filtered := map[string]string{}
+	for k, v := range annotations {
+		filtered[k] = v
+	}
+
+	for _, k := range provenanceAnnotations {
+		delete(filtered, k)
+	}
+
+	return filtered
+}
+
+// isManaged returns whether app-checker created or last updated the given App
+// CR. Only managed App CRs are removed.
+func isManaged(cr *v1alpha1.App) bool {
+	return cr.Labels[label.ManagedBy] == label.ManagedByValue() && cr.Annotations[annotation.DeploymentID] != ""
+}
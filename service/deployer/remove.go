@@ -0,0 +1,316 @@
+package deployer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v3/pkg/apis/application/v1alpha1"
+	"github.com/giantswarm/microerror"
+	"github.com/google/go-github/v32/github"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/app-checker/service/appwatcher"
+)
+
+const (
+	// TaskRemove is the GitHub deployment task requesting the removal of the
+	// App CR the deployment targets.
+	TaskRemove = "remove"
+	// TaskRemoveBranch is the task of jobs removing the App CRs deployed from
+	// a deleted branch. These jobs have no GitHub deployment and are expanded
+	// into removals through the latest deployment of every App CR.
+	TaskRemoveBranch = "remove:branch"
+)
+
+// remove deletes the App CR of the given removal deployment and waits for
+// app-operator to finalize its deletion. A removed app is reported as
+// inactive, as its environment does not exist anymore. App CRs app-checker
+// does not manage are left untouched. The caller must hold the lock of the
+// App CR.
+func (d *Deployer) remove(ctx context.Context, event *github.DeploymentEvent, payload *Payload, cr *v1alpha1.App) error {
+	name := cr.GetName()
+	namespace := cr.GetNamespace()
+
+	timeout, err := d.timeout(payload)
+	if IsInvalidDeployment(err) {
+		reason := microerror.Pretty(err, false)
+
+		d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("cannot remove app %#q: %s", name, reason))
+
+		err = d.updateGithubDeploymentStatus(ctx, event, cr, "failure", reason)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	sub := d.appWatcher.Subscribe(namespace, name)
+	defer sub.Close()
+
+	current, err := d.k8sClient.G8sClient().ApplicationV1alpha1().Apps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("app %#q does not exist", name))
+
+		err = d.updateGithubDeploymentStatus(ctx, event, cr, "inactive", fmt.Sprintf("app %s does not exist", name))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if !isManaged(current) {
+		d.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("not removing app %#q in namespace %#q, it is not managed by app-checker", name, namespace))
+
+		err = d.updateGithubDeploymentStatus(ctx, event, cr, "failure", fmt.Sprintf("app %s is not managed by app-checker", name))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	// The precondition keeps an App CR which got replaced in the meantime.
+	opts := metav1.DeleteOptions{
+		Preconditions: metav1.NewUIDPreconditions(string(current.GetUID())),
+	}
+
+	err = d.k8sClient.G8sClient().ApplicationV1alpha1().Apps(namespace).Delete(ctx, name, opts)
+	if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
+		d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("app %#q does not exist anymore", name))
+
+		err = d.updateGithubDeploymentStatus(ctx, event, cr, "inactive", fmt.Sprintf("app %s does not exist", name))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("removing app %#q", name))
+
+	err = d.updateGithubDeploymentStatus(ctx, event, cr, "in_progress", fmt.Sprintf("removing app %s", name))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	removed, err := d.waitForRemoval(ctx, sub, timeout)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if !removed {
+		d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("app %#q was not removed within %s", name, timeout))
+
+		err = d.updateGithubDeploymentStatus(ctx, event, cr, "failure", fmt.Sprintf("removal took longer than %s. check app-operator logs", timeout))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+	}
+
+	d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("removed app %#q", name))
+
+	err = d.updateGithubDeploymentStatus(ctx, event, cr, "inactive", fmt.Sprintf("removed app %s", name))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = d.cleanupUserConfig(ctx, namespace, name)
+	if err != nil {
+		d.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to clean up user config of app %#q", name), "stack", fmt.Sprintf("%#v", err))
+	}
+
+	return nil
+}
+
+// waitForRemoval waits for the App CR of the given subscription to be deleted.
+// It returns false when the timeout expires first.
+func (d *Deployer) waitForRemoval(ctx context.Context, sub *appwatcher.Subscription, timeout time.Duration) (bool, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-sub.Deleted():
+		return true, nil
+	case <-timer.C:
+		return false, nil
+	case <-ctx.Done():
+		return false, microerror.Mask(ctx.Err())
+	}
+}
+
+// BranchRemovalEvent returns the job removing the App CRs deployed from the
+// branch deleted by the given event. The job is queued like any deployment
+// event, so the GitHub API calls listing the deployments of the branch happen
+// outside of the webhook delivery. It returns nil for refs other than
+// branches.
+func (d *Deployer) BranchRemovalEvent(event *github.DeleteEvent) *github.DeploymentEvent {
+	if event.GetRefType() != "branch" {
+		return nil
+	}
+
+	id := d.nextManualID()
+	task := TaskRemoveBranch
+	ref := event.GetRef()
+
+	e := &github.DeploymentEvent{
+		Deployment: &github.Deployment{
+			ID:          &id,
+			CreatedAt:   &github.Timestamp{Time: time.Now()},
+			Environment: &d.env,
+			Ref:         &ref,
+			Task:        &task,
+		},
+		Repo:         event.Repo,
+		Installation: event.Installation,
+	}
+
+	return e
+}
+
+// removeBranch removes the App CRs deployed from the branch of the given
+// branch removal job. Every App CR is removed through the latest deployment
+// to it, which gets the outcome reported.
+func (d *Deployer) removeBranch(ctx context.Context, event *github.DeploymentEvent) error {
+	removals, err := d.branchRemovals(ctx, event)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if len(removals) == 0 {
+		d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("branch %#q of repository %#q has no apps deployed to %#q", event.Deployment.GetRef(), event.Repo.GetName(), d.env))
+		return nil
+	}
+
+	d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("removing %d apps of branch %#q of repository %#q", len(removals), event.Deployment.GetRef(), event.Repo.GetName()))
+
+	// Removals of different App CRs do not depend on each other, and every
+	// one of them waits for app-operator to finalize the deletion.
+	errs := make(chan error, len(removals))
+	for _, removal := range removals {
+		go func(removal *github.DeploymentEvent) {
+			errs <- d.ProcessDeploymentEvent(ctx, removal)
+		}(removal)
+	}
+
+	var failed int
+	for range removals {
+		err := <-errs
+		if err != nil {
+			d.logger.LogCtx(ctx, "level", "error", "message", "failed to remove app of deleted branch", "stack", fmt.Sprintf("%#v", err))
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return microerror.Maskf(executionFailedError, "%d of %d app removals of branch %#q failed", failed, len(removals), event.Deployment.GetRef())
+	}
+
+	return nil
+}
+
+// branchRemovals returns removal deployment events for the App CRs deployed
+// from the branch of the given branch removal job.
+func (d *Deployer) branchRemovals(ctx context.Context, event *github.DeploymentEvent) ([]*github.DeploymentEvent, error) {
+	deployments, err := d.latestDeployments(ctx, event.Repo.GetOwner().GetLogin(), event.Repo.GetName(), event.Deployment.GetRef())
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var events []*github.DeploymentEvent
+	for _, deployment := range deployments {
+		events = append(events, NewRemovalEvent(deployment, event.Repo, event.Installation))
+	}
+
+	return events, nil
+}
+
+// LatestDeployment returns the latest deployment of the given ref to the App
+// CR with the given namespace and name, or nil when there is none.
+func (d *Deployer) LatestDeployment(ctx context.Context, owner, repository, ref, namespace, name string) (*github.Deployment, error) {
+	deployments, err := d.latestDeployments(ctx, owner, repository, ref)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return deployments[namespace+"/"+name], nil
+}
+
+// latestDeployments returns the latest deployment of every App CR deployed
+// from the given ref, keyed by App CR namespace and name. Deployments of
+// unique App CRs are left out as they are shared across refs.
+func (d *Deployer) latestDeployments(ctx context.Context, owner, repository, ref string) (map[string]*github.Deployment, error) {
+	opts := &github.DeploymentsListOptions{
+		Environment: d.env,
+		Ref:         ref,
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	var deployments []*github.Deployment
+	for {
+		page, res, err := d.githubClient.Repositories.ListDeployments(ctx, owner, repository, opts)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		deployments = append(deployments, page...)
+
+		if res.NextPage == 0 {
+			break
+		}
+		opts.Page = res.NextPage
+	}
+
+	// Deployments are listed newest first, so the first deployment of every
+	// App CR is its latest one.
+	latest := map[string]*github.Deployment{}
+	for _, deployment := range deployments {
+		payload, err := ParseTaskPayload(deployment.GetTask(), deployment.Payload)
+		if err != nil {
+			// Deployments app-checker did not handle are none of our concern.
+			continue
+		}
+		if payload.Unique {
+			continue
+		}
+
+		key := toAppCRNamespace(payload) + "/" + toAppCRName(repository, ref, payload)
+		if _, ok := latest[key]; ok {
+			continue
+		}
+
+		latest[key] = deployment
+	}
+
+	return latest, nil
+}
+
+// NewRemovalEvent returns a deployment event removing the App CR of the given
+// deployment. The outcome is reported as status of the given deployment.
+func NewRemovalEvent(deployment *github.Deployment, repo *github.Repository, installation *github.Installation) *github.DeploymentEvent {
+	task := TaskRemove
+
+	removal := *deployment
+	removal.Task = &task
+
+	e := &github.DeploymentEvent{
+		Deployment:   &removal,
+		Repo:         repo,
+		Installation: installation,
+	}
+
+	return e
+}
@@ -0,0 +1,67 @@
+package deployer
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/giantswarm/microerror"
+	"github.com/google/go-github/v32/github"
+
+	"github.com/giantswarm/app-checker/pkg/githubapp"
+)
+
+// RetryEvent returns a deployment event processing the given GitHub
+// deployment again. The deployment is fetched from GitHub, so the event is the
+// same as the originally delivered one. Installation ID is zero when
+// authenticating with an OAuth token.
+func (d *Deployer) RetryEvent(ctx context.Context, owner, repository string, id, installationID int64) (*github.DeploymentEvent, error) {
+	var installation *github.Installation
+	if installationID != 0 {
+		ctx = githubapp.WithInstallationID(ctx, installationID)
+		installation = &github.Installation{ID: &installationID}
+	}
+
+	deployment, res, err := d.githubClient.Repositories.GetDeployment(ctx, owner, repository, id)
+	if res != nil && res.StatusCode == http.StatusNotFound {
+		return nil, microerror.Maskf(notFoundError, "deployment %d does not exist in repository %s/%s", id, owner, repository)
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	e := &github.DeploymentEvent{
+		Deployment: deployment,
+		Repo: &github.Repository{
+			FullName: github.String(owner + "/" + repository),
+			Name:     github.String(repository),
+			Owner: &github.User{
+				Login: github.String(owner),
+			},
+		},
+		Installation: installation,
+	}
+
+	return e, nil
+}
+
+// SupersededBy returns the ID of the newer deployment of the same App CR which
+// supersedes the given deployment event, or zero. Processing a superseded
+// deployment event again only reports it as superseded.
+func (d *Deployer) SupersededBy(event *github.DeploymentEvent) (int64, error) {
+	payload, err := ParseTaskPayload(event.Deployment.GetTask(), event.Deployment.Payload)
+	if err != nil {
+		return 0, microerror.Mask(err)
+	}
+
+	// Dry runs do not take the lock of their App CR, so they are never
+	// superseded.
+	if d.dryRun || payload.DryRun {
+		return 0, nil
+	}
+
+	appCRName := toAppCRName(event.Repo.GetName(), event.Deployment.GetRef(), payload)
+	appCRNamespace := toAppCRNamespace(payload)
+
+	key := appCRNamespace + "/" + appCRName
+
+	return d.locks.supersededBy(key, positionOf(event)), nil
+}
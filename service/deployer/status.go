@@ -0,0 +1,253 @@
+package deployer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/giantswarm/apiextensions/v3/pkg/apis/application/v1alpha1"
+	"github.com/giantswarm/app/v4/pkg/key"
+	"github.com/giantswarm/microerror"
+	"github.com/google/go-github/v32/github"
+)
+
+// EnvironmentURLData is available to the environment URL template.
+type EnvironmentURLData struct {
+	AppCatalog     string
+	AppCRName      string
+	AppName        string
+	AppNamespace   string
+	AppVersion     string
+	Cluster        string
+	Environment    string
+	Ref            string
+	Repository     string
+	WebhookBaseURL string
+}
+
+func (d *Deployer) reportStatus(ctx context.Context, event *github.DeploymentEvent, cr *v1alpha1.App, status, reason string) error {
+	var err error
+	switch status {
+	case "deployed":
+		err = d.updateGithubDeploymentStatus(ctx, event, cr, "success", "")
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		// Manual deployments without GitHub deployment have no place in the
+		// GitHub deployment history. The App CR is deployed at this point, so
+		// failing to inactivate older deployments must not fail this one.
+		if d.autoInactive && !isManual(event) {
+			err = d.inactivatePreviousDeployments(ctx, event, cr)
+			if err != nil {
+				d.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to inactivate deployments preceding deployment %d", event.Deployment.GetID()), "stack", fmt.Sprintf("%#v", err))
+			}
+		}
+
+		return nil
+
+	case "not-installed", "failed":
+		err = d.updateGithubDeploymentStatus(ctx, event, cr, "failure", reason)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		return nil
+
+	default:
+		err = d.updateGithubDeploymentStatus(ctx, event, cr, "pending", reason)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// countDeployment counts the outcome of a deployment with the given final
+// release status. Only deployments which got rolled out or rejected are
+// counted, so deployments found deployed already are not counted twice.
+func countDeployment(cr *v1alpha1.App, status string) {
+	switch status {
+	case "deployed":
+		deploymentCounter.WithLabelValues(key.CatalogName(*cr), "success").Inc()
+	case "not-installed", "failed":
+		deploymentCounter.WithLabelValues(key.CatalogName(*cr), "failure").Inc()
+	}
+}
+
+func (d *Deployer) updateGithubDeploymentStatus(ctx context.Context, event *github.DeploymentEvent, cr *v1alpha1.App, status, reason string) error {
+	if len(reason) >= 140 {
+		reason = reason[0:137] + "..."
+	}
+
+	environmentURL, err := d.environmentURL(event, cr)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// GitHub would inactivate all older deployments of the repository and
+	// environment, including the ones of other App CRs. So we take care of it
+	// ourselves in inactivatePreviousDeployments.
+	autoInactive := false
+	logURL := fmt.Sprintf("%s/status/deployments/%d?repository=%s", d.webhookBaseURL, event.Deployment.GetID(), url.QueryEscape(event.Repo.GetName()))
+
+	request := github.DeploymentStatusRequest{
+		State:        &status,
+		Description:  &reason,
+		Environment:  &d.env,
+		AutoInactive: &autoInactive,
+	}
+	if d.webhookBaseURL != "" {
+		request.LogURL = &logURL
+	}
+	if environmentURL != "" {
+		request.EnvironmentURL = &environmentURL
+	}
+
+	d.tracker.AddStatus(event.Deployment.GetID(), status, reason)
+	d.recordStatus(ctx, event.Repo.GetName(), event.Deployment.GetID(), status, reason)
+
+	// Manual deployments without GitHub deployment have nothing to report
+	// to.
+	if isManual(event) {
+		return nil
+	}
+
+	// The reporter retries transient failures and re-sends the status in the
+	// background, so failing to report it must not abort the deployment.
+	err = d.reporter.Report(ctx, event.Repo.GetOwner().GetLogin(), event.Repo.GetName(), event.Deployment.GetID(), request)
+	if err != nil {
+		d.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to report status %#q of deployment %d", status, event.Deployment.GetID()), "stack", fmt.Sprintf("%#v", err))
+	}
+
+	return nil
+}
+
+// LastStatus returns the latest GitHub deployment status state and
+// description reported for the given deployment, or empty strings when none
+// was reported. Deployments processed before the last restart are looked up in
+// the deployment history.
+func (d *Deployer) LastStatus(ctx context.Context, repository string, id int64) (string, string, error) {
+	deployment, ok := d.tracker.Get(id)
+	if ok && len(deployment.Statuses) > 0 {
+		last := deployment.Statuses[len(deployment.Statuses)-1]
+		return last.State, last.Description, nil
+	}
+
+	state, description, err := d.history.Status(ctx, repository, id)
+	if err != nil {
+		return "", "", microerror.Mask(err)
+	}
+
+	return state, description, nil
+}
+
+// recordStatus adds a reported status to the deployment history. Failures are
+// only logged as the history is informational.
+func (d *Deployer) recordStatus(ctx context.Context, repository string, id int64, state, description string) {
+	err := d.history.AddStatus(ctx, repository, id, state, description)
+	if err != nil {
+		d.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to record status %#q of deployment %d", state, id), "stack", fmt.Sprintf("%#v", err))
+	}
+}
+
+func (d *Deployer) environmentURL(event *github.DeploymentEvent, cr *v1alpha1.App) (string, error) {
+	if d.environmentURLTemplate == nil {
+		return "", nil
+	}
+
+	data := EnvironmentURLData{
+		AppCatalog:     key.CatalogName(*cr),
+		AppCRName:      cr.GetName(),
+		AppName:        key.AppName(*cr),
+		AppNamespace:   cr.Spec.Namespace,
+		AppVersion:     key.Version(*cr),
+		Cluster:        cluster(cr),
+		Environment:    d.env,
+		Ref:            event.Deployment.GetRef(),
+		Repository:     event.Repo.GetName(),
+		WebhookBaseURL: d.webhookBaseURL,
+	}
+
+	var b bytes.Buffer
+	err := d.environmentURLTemplate.Execute(&b, data)
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return b.String(), nil
+}
+
+// inactivatePreviousDeployments marks older successful deployments to the
+// same App CR inactive.
+func (d *Deployer) inactivatePreviousDeployments(ctx context.Context, event *github.DeploymentEvent, cr *v1alpha1.App) error {
+	owner := event.Repo.GetOwner().GetLogin()
+	repository := event.Repo.GetName()
+
+	opts := &github.DeploymentsListOptions{
+		Environment: d.env,
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	deployments, _, err := d.githubClient.Repositories.ListDeployments(ctx, owner, repository, opts)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, deployment := range deployments {
+		if deployment.GetID() >= event.Deployment.GetID() {
+			continue
+		}
+
+		payload, err := ParsePayload(deployment.Payload)
+		if err != nil {
+			// Deployments app-checker did not handle are none of our concern.
+			continue
+		}
+		if toAppCRNamespace(payload) != cr.GetNamespace() || toAppCRName(repository, deployment.GetRef(), payload) != cr.GetName() {
+			continue
+		}
+
+		statuses, _, err := d.githubClient.Repositories.ListDeploymentStatuses(ctx, owner, repository, deployment.GetID(), &github.ListOptions{PerPage: 1})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+		if len(statuses) == 0 || statuses[0].GetState() != "success" {
+			continue
+		}
+
+		state := "inactive"
+		description := fmt.Sprintf("superseded by deployment %d", event.Deployment.GetID())
+		request := github.DeploymentStatusRequest{
+			State:       &state,
+			Description: &description,
+			Environment: &d.env,
+		}
+
+		err = d.reporter.Report(ctx, owner, repository, deployment.GetID(), request)
+		if err != nil {
+			d.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to mark deployment %d of app %#q inactive", deployment.GetID(), cr.GetName()), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		d.recordStatus(ctx, repository, deployment.GetID(), state, description)
+
+		d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("marked deployment %d of app %#q inactive", deployment.GetID(), cr.GetName()))
+	}
+
+	return nil
+}
+
+// cluster returns the ID of the workload cluster the given App CR is deployed
+// to, or an empty string for the management cluster.
+func cluster(cr *v1alpha1.App) string {
+	if cr.Spec.KubeConfig.InCluster {
+		return ""
+	}
+
+	return cr.Spec.KubeConfig.Secret.Namespace
+}
@@ -0,0 +1,65 @@
+package deployer
+
+// Payload is the deployer specific payload of a GitHub deployment.
+type Payload struct {
+	AppVersion string `json:"appVersion"`
+	Chart      string `json:"chart"`
+	// Cluster is the ID of the workload cluster the app is deployed to. Apps
+	// are deployed to the management cluster when it is empty.
+	Cluster string `json:"cluster,omitempty"`
+	// DryRun only computes and reports the App CR changes of the deployment
+	// without applying them.
+	DryRun    bool   `json:"dryRun,omitempty"`
+	Namespace string `json:"namespace"`
+	// Rollback requests restoring the previous App CR spec when the
+	// deployment fails. When unset the configured default applies.
+	Rollback *bool `json:"rollback,omitempty"`
+	// Timeout is the time to wait for the app to settle as Go duration, e.g.
+	// `5m`. It is bounded by the configured maximum timeout.
+	Timeout string `json:"timeout,omitempty"`
+	Unique  bool   `json:"unique"`
+	// UserConfig configures the user values of the app.
+	UserConfig *PayloadUserConfig `json:"userConfig,omitempty"`
+}
+
+// PayloadUserConfig configures the user values of an app. app-checker
+// copies them into a ConfigMap and Secret it manages for the App CR.
+type PayloadUserConfig struct {
+	// ConfigMap references an existing ConfigMap whose `values` are used as
+	// base of the user values.
+	ConfigMap *PayloadReference `json:"configMap,omitempty"`
+	// Secret references an existing Secret whose `values` are used as user
+	// secrets.
+	Secret *PayloadReference `json:"secret,omitempty"`
+	// Values are inline user values. They take precedence over the values of
+	// the referenced ConfigMap.
+	Values map[string]interface{} `json:"values,omitempty"`
+}
+
+// PayloadReference references a ConfigMap or Secret. The namespace must be
+// the namespace of the App CR, which it defaults to.
+type PayloadReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ManualDeployment requests deploying an app without a GitHub deployment
+// event, e.g. while GitHub webhooks are down.
+type ManualDeployment struct {
+	// CreateDeployment creates a GitHub deployment the deployment statuses
+	// are reported to. Otherwise the deployment is only recorded by
+	// app-checker.
+	CreateDeployment bool
+	// InstallationID is the GitHub App installation to authenticate as. It is
+	// only needed when authenticating as GitHub App without configured
+	// installation.
+	InstallationID int64
+	Owner          string
+	// Payload is the raw deployment payload.
+	Payload    []byte
+	Ref        string
+	Repository string
+	// SHA is the commit SHA recorded for deployments without GitHub
+	// deployment. GitHub resolves it from the ref otherwise.
+	SHA string
+}
@@ -0,0 +1,331 @@
+package deployer
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+
+	"github.com/giantswarm/apiextensions/v3/pkg/apis/application/v1alpha1"
+	"github.com/giantswarm/microerror"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/giantswarm/app-checker/pkg/label"
+)
+
+const (
+	// userConfigAppAnnotation names the App CR a managed user config
+	// ConfigMap or Secret belongs to. App CR names can exceed the length of
+	// label values, so it is an annotation.
+	userConfigAppAnnotation = "app-checker.giantswarm.io/app"
+	userConfigLabel         = "app-checker.giantswarm.io/user-config"
+	// userConfigValuesKey is the key app-operator reads user values from.
+	userConfigValuesKey = "values"
+)
+
+// ensureUserConfig creates or updates the user config ConfigMap and Secret
+// requested by the deployment payload next to the desired App CR and wires
+// them into it. Their names are derived from their content, so changed user
+// config changes the App CR spec and the previous user config is left intact
+// for rollbacks. Referenced ConfigMaps and Secrets must live in the namespace
+// of the App CR, so deployments cannot copy arbitrary Secrets of the cluster.
+// Dry runs only wire them into the desired App CR without creating them.
+func (d *Deployer) ensureUserConfig(ctx context.Context, payload *Payload, desiredAppCR *v1alpha1.App, dryRun bool) error {
+	userConfig := payload.UserConfig
+	if userConfig == nil {
+		return nil
+	}
+
+	if userConfig.ConfigMap != nil || len(userConfig.Values) > 0 {
+		values := map[string]interface{}{}
+
+		if ref := userConfig.ConfigMap; ref != nil {
+			namespace, err := referenceNamespace(ref, desiredAppCR)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			cm, err := d.k8sClient.K8sClient().CoreV1().ConfigMaps(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				return microerror.Maskf(invalidDeploymentError, "user config ConfigMap %#q in namespace %#q not found", ref.Name, namespace)
+			} else if err != nil {
+				return microerror.Mask(err)
+			}
+
+			err = yaml.Unmarshal([]byte(cm.Data[userConfigValuesKey]), &values)
+			if err != nil {
+				return microerror.Maskf(invalidDeploymentError, "user config ConfigMap %#q in namespace %#q has invalid %#q", ref.Name, namespace, userConfigValuesKey)
+			}
+		}
+
+		mergeValues(values, userConfig.Values)
+
+		b, err := yaml.Marshal(values)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		cm := &corev1.ConfigMap{
+			ObjectMeta: userConfigMeta(desiredAppCR, "user-values", b),
+			Data: map[string]string{
+				userConfigValuesKey: string(b),
+			},
+		}
+
+		if !dryRun {
+			err = d.applyUserConfigMap(ctx, cm)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		desiredAppCR.Spec.UserConfig.ConfigMap = v1alpha1.AppSpecUserConfigConfigMap{
+			Name:      cm.Name,
+			Namespace: cm.Namespace,
+		}
+	}
+
+	if ref := userConfig.Secret; ref != nil {
+		namespace, err := referenceNamespace(ref, desiredAppCR)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		referenced, err := d.k8sClient.K8sClient().CoreV1().Secrets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return microerror.Maskf(invalidDeploymentError, "user config Secret %#q in namespace %#q not found", ref.Name, namespace)
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		b, ok := referenced.Data[userConfigValuesKey]
+		if !ok {
+			return microerror.Maskf(invalidDeploymentError, "user config Secret %#q in namespace %#q has no %#q", ref.Name, namespace, userConfigValuesKey)
+		}
+
+		secret := &corev1.Secret{
+			ObjectMeta: userConfigMeta(desiredAppCR, "user-secrets", b),
+			Data: map[string][]byte{
+				userConfigValuesKey: b,
+			},
+		}
+
+		if !dryRun {
+			err = d.applyUserSecret(ctx, secret)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+		}
+
+		desiredAppCR.Spec.UserConfig.Secret = v1alpha1.AppSpecUserConfigSecret{
+			Name:      secret.Name,
+			Namespace: secret.Namespace,
+		}
+	}
+
+	return nil
+}
+
+// applyUserConfigMap creates the given managed user config ConfigMap or
+// updates the existing one when its data or metadata drifted, e.g. because it
+// got edited manually.
+func (d *Deployer) applyUserConfigMap(ctx context.Context, cm *corev1.ConfigMap) error {
+	_, err := d.k8sClient.K8sClient().CoreV1().ConfigMaps(cm.Namespace).Create(ctx, cm, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		// fall through
+	} else if err != nil {
+		return microerror.Mask(err)
+	} else {
+		d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("created user config ConfigMap %#q", cm.Name))
+		return nil
+	}
+
+	current, err := d.k8sClient.K8sClient().CoreV1().ConfigMaps(cm.Namespace).Get(ctx, cm.Name, metav1.GetOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if reflect.DeepEqual(current.Data, cm.Data) && containsAll(current.Labels, cm.Labels) && containsAll(current.Annotations, cm.Annotations) {
+		return nil
+	}
+
+	updated := current.DeepCopy()
+	updated.Data = cm.Data
+	updated.BinaryData = nil
+	updated.Labels = merge(updated.Labels, cm.Labels)
+	updated.Annotations = merge(updated.Annotations, cm.Annotations)
+
+	_, err = d.k8sClient.K8sClient().CoreV1().ConfigMaps(cm.Namespace).Update(ctx, updated, metav1.UpdateOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("updated user config ConfigMap %#q", cm.Name))
+
+	return nil
+}
+
+// applyUserSecret creates the given managed user config Secret or updates the
+// existing one when its data or metadata drifted.
+func (d *Deployer) applyUserSecret(ctx context.Context, secret *corev1.Secret) error {
+	_, err := d.k8sClient.K8sClient().CoreV1().Secrets(secret.Namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		// fall through
+	} else if err != nil {
+		return microerror.Mask(err)
+	} else {
+		d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("created user config Secret %#q", secret.Name))
+		return nil
+	}
+
+	current, err := d.k8sClient.K8sClient().CoreV1().Secrets(secret.Namespace).Get(ctx, secret.Name, metav1.GetOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if reflect.DeepEqual(current.Data, secret.Data) && containsAll(current.Labels, secret.Labels) && containsAll(current.Annotations, secret.Annotations) {
+		return nil
+	}
+
+	updated := current.DeepCopy()
+	updated.Data = secret.Data
+	updated.StringData = nil
+	updated.Labels = merge(updated.Labels, secret.Labels)
+	updated.Annotations = merge(updated.Annotations, secret.Annotations)
+
+	_, err = d.k8sClient.K8sClient().CoreV1().Secrets(secret.Namespace).Update(ctx, updated, metav1.UpdateOptions{})
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("updated user config Secret %#q", secret.Name))
+
+	return nil
+}
+
+// cleanupUserConfig deletes the managed user config ConfigMaps and Secrets of
+// the given App CR which its current spec does not reference anymore. All of
+// them are deleted once the App CR got removed.
+func (d *Deployer) cleanupUserConfig(ctx context.Context, namespace, name string) error {
+	currentApp, err := d.k8sClient.G8sClient().ApplicationV1alpha1().Apps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		currentApp = &v1alpha1.App{}
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	lo := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true", userConfigLabel),
+	}
+
+	configMaps, err := d.k8sClient.K8sClient().CoreV1().ConfigMaps(namespace).List(ctx, lo)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, cm := range configMaps.Items {
+		if cm.Annotations[userConfigAppAnnotation] != name || cm.Name == currentApp.Spec.UserConfig.ConfigMap.Name {
+			continue
+		}
+
+		err = d.k8sClient.K8sClient().CoreV1().ConfigMaps(namespace).Delete(ctx, cm.Name, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleted unused user config ConfigMap %#q", cm.Name))
+	}
+
+	secrets, err := d.k8sClient.K8sClient().CoreV1().Secrets(namespace).List(ctx, lo)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	for _, secret := range secrets.Items {
+		if secret.Annotations[userConfigAppAnnotation] != name || secret.Name == currentApp.Spec.UserConfig.Secret.Name {
+			continue
+		}
+
+		err = d.k8sClient.K8sClient().CoreV1().Secrets(namespace).Delete(ctx, secret.Name, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			// fall through
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("deleted unused user config Secret %#q", secret.Name))
+	}
+
+	return nil
+}
+
+// userConfigMeta returns the metadata of a managed user config ConfigMap or
+// Secret of the given App CR holding content.
+func userConfigMeta(cr *v1alpha1.App, suffix string, content []byte) metav1.ObjectMeta {
+	hash := fmt.Sprintf("%x", sha256.Sum256(content))
+
+	return metav1.ObjectMeta{
+		Name:      fmt.Sprintf("%s-%s-%s", cr.GetName(), suffix, hash[:10]),
+		Namespace: cr.GetNamespace(),
+		Annotations: map[string]string{
+			userConfigAppAnnotation: cr.GetName(),
+		},
+		Labels: map[string]string{
+			label.ManagedBy: label.ManagedByValue(),
+			userConfigLabel: "true",
+		},
+	}
+}
+
+// mergeValues merges src into dst recursively. Values of src take precedence.
+func mergeValues(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, srcOK := v.(map[string]interface{})
+		dstMap, dstOK := dst[k].(map[string]interface{})
+		if srcOK && dstOK {
+			mergeValues(dstMap, srcMap)
+			continue
+		}
+
+		dst[k] = v
+	}
+}
+
+// referenceNamespace returns the namespace of the given user config
+// reference. app-checker may read Secrets of any namespace, so only
+// references in the namespace of the App CR are resolved.
+func referenceNamespace(ref *PayloadReference, cr *v1alpha1.App) (string, error) {
+	if ref.Namespace != "" && ref.Namespace != cr.GetNamespace() {
+		return "", microerror.Maskf(invalidDeploymentError, "user config %#q must be in namespace %#q of the App CR, not %#q", ref.Name, cr.GetNamespace(), ref.Namespace)
+	}
+
+	return cr.GetNamespace(), nil
+}
+
+// containsAll returns whether m contains all entries of subset.
+func containsAll(m, subset map[string]string) bool {
+	for k, v := range subset {
+		if m[k] != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// merge returns m with all entries of src set.
+func merge(m, src map[string]string) map[string]string {
+	if m == nil {
+		m = map[string]string{}
+	}
+	for k, v := range src {
+		m[k] = v
+	}
+
+	return m
+}
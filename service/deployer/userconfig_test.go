@@ -0,0 +1,200 @@
+package deployer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/giantswarm/apiextensions/v3/pkg/apis/application/v1alpha1"
+	"github.com/giantswarm/k8sclient/v5/pkg/k8sclienttest"
+	"github.com/giantswarm/micrologger/microloggertest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_Deployer_ensureUserConfig(t *testing.T) {
+	testCases := []struct {
+		name       string
+		objects    []runtime.Object
+		userConfig *PayloadUserConfig
+		// expectedValues is the content of the managed user values
+		// ConfigMap, if any.
+		expectedValues string
+		// expectedSecret is the content of the managed user secrets Secret,
+		// if any.
+		expectedSecret string
+		errorMatcher   func(error) bool
+	}{
+		{
+			name: "case 0: inline values",
+			userConfig: &PayloadUserConfig{
+				Values: map[string]interface{}{"replicas": 3},
+			},
+			expectedValues: "replicas: 3\n",
+		},
+		{
+			name: "case 1: ConfigMap in namespace of the App CR merged with inline values",
+			objects: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "my-values", Namespace: "giantswarm"},
+					Data:       map[string]string{"values": "replicas: 1\nimage: foo\n"},
+				},
+			},
+			userConfig: &PayloadUserConfig{
+				ConfigMap: &PayloadReference{Name: "my-values"},
+				Values:    map[string]interface{}{"replicas": 3},
+			},
+			expectedValues: "image: foo\nreplicas: 3\n",
+		},
+		{
+			name: "case 2: ConfigMap in other namespace",
+			objects: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "my-values", Namespace: "kube-system"},
+					Data:       map[string]string{"values": "replicas: 1\n"},
+				},
+			},
+			userConfig: &PayloadUserConfig{
+				ConfigMap: &PayloadReference{Name: "my-values", Namespace: "kube-system"},
+			},
+			errorMatcher: IsInvalidDeployment,
+		},
+		{
+			name: "case 3: Secret in namespace of the App CR",
+			objects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "my-secrets", Namespace: "giantswarm"},
+					Data:       map[string][]byte{"values": []byte("password: secret\n")},
+				},
+			},
+			userConfig: &PayloadUserConfig{
+				Secret: &PayloadReference{Name: "my-secrets", Namespace: "giantswarm"},
+			},
+			expectedSecret: "password: secret\n",
+		},
+		{
+			name: "case 4: Secret in other namespace",
+			objects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "my-secrets", Namespace: "kube-system"},
+					Data:       map[string][]byte{"values": []byte("password: secret\n")},
+				},
+			},
+			userConfig: &PayloadUserConfig{
+				Secret: &PayloadReference{Name: "my-secrets", Namespace: "kube-system"},
+			},
+			errorMatcher: IsInvalidDeployment,
+		},
+		{
+			name: "case 5: missing ConfigMap",
+			userConfig: &PayloadUserConfig{
+				ConfigMap: &PayloadReference{Name: "my-values"},
+			},
+			errorMatcher: IsInvalidDeployment,
+		},
+		{
+			name: "case 6: drifted managed ConfigMap is updated",
+			objects: []runtime.Object{
+				&corev1.ConfigMap{
+					ObjectMeta: userConfigMeta(testApp(), "user-values", []byte("replicas: 3\n")),
+					Data:       map[string]string{"values": "replicas: 5\n"},
+				},
+			},
+			userConfig: &PayloadUserConfig{
+				Values: map[string]interface{}{"replicas": 3},
+			},
+			expectedValues: "replicas: 3\n",
+		},
+		{
+			name: "case 7: drifted managed Secret is updated",
+			objects: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "my-secrets", Namespace: "giantswarm"},
+					Data:       map[string][]byte{"values": []byte("password: secret\n")},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      userConfigMeta(testApp(), "user-secrets", []byte("password: secret\n")).Name,
+						Namespace: "giantswarm",
+					},
+					Data: map[string][]byte{"values": []byte("password: changed\n")},
+				},
+			},
+			userConfig: &PayloadUserConfig{
+				Secret: &PayloadReference{Name: "my-secrets"},
+			},
+			expectedSecret: "password: secret\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			k8sClient := fake.NewSimpleClientset(tc.objects...)
+
+			d := &Deployer{
+				k8sClient: k8sclienttest.NewClients(k8sclienttest.ClientsConfig{
+					K8sClient: k8sClient,
+				}),
+				logger: microloggertest.New(),
+			}
+
+			cr := testApp()
+
+			err := d.ensureUserConfig(ctx, &Payload{UserConfig: tc.userConfig}, cr, false)
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("expected nil, got %#v", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("expected error, got nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("unexpected error %#v", err)
+			}
+
+			if tc.expectedValues != "" {
+				ref := cr.Spec.UserConfig.ConfigMap
+				if ref.Namespace != cr.Namespace {
+					t.Fatalf("expected namespace %#q, got %#q", cr.Namespace, ref.Namespace)
+				}
+
+				cm, err := k8sClient.CoreV1().ConfigMaps(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("expected nil, got %#v", err)
+				}
+				if cm.Data["values"] != tc.expectedValues {
+					t.Fatalf("expected values %#q, got %#q", tc.expectedValues, cm.Data["values"])
+				}
+				if cm.Labels[userConfigLabel] != "true" {
+					t.Fatalf("expected label %#q to be set", userConfigLabel)
+				}
+			}
+
+			if tc.expectedSecret != "" {
+				ref := cr.Spec.UserConfig.Secret
+
+				secret, err := k8sClient.CoreV1().Secrets(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("expected nil, got %#v", err)
+				}
+				if string(secret.Data["values"]) != tc.expectedSecret {
+					t.Fatalf("expected secret %#q, got %#q", tc.expectedSecret, secret.Data["values"])
+				}
+				if secret.Labels[userConfigLabel] != "true" {
+					t.Fatalf("expected label %#q to be set", userConfigLabel)
+				}
+			}
+		})
+	}
+}
+
+func testApp() *v1alpha1.App {
+	return &v1alpha1.App{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app-operator-master",
+			Namespace: "giantswarm",
+		},
+	}
+}
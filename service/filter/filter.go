@@ -0,0 +1,92 @@
+// Package filter decides which repositories app-checker deploys for based on
+// configurable allow and deny lists of repository name and owner patterns.
+package filter
+
+import (
+	"fmt"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/app-checker/pkg/pattern"
+)
+
+// DefaultDenyNames are repositories whose deployments are handled by
+// draughtsman instead of app-checker.
+func DefaultDenyNames() []string {
+	return []string{
+		"draughtsman",
+		"aws-app-collection",
+		"azure-app-collection",
+		"kvm-app-collection",
+		"conformance-app-collection",
+	}
+}
+
+type Config struct {
+	// AllowNames and AllowOwners, when not empty, restrict deployments to
+	// repositories matching at least one of their patterns.
+	AllowNames  []string
+	AllowOwners []string
+	// DenyNames and DenyOwners exclude repositories matching any of their
+	// patterns. They take precedence over the allow lists.
+	DenyNames  []string
+	DenyOwners []string
+}
+
+type Filter struct {
+	allowNames  []*pattern.Pattern
+	allowOwners []*pattern.Pattern
+	denyNames   []*pattern.Pattern
+	denyOwners  []*pattern.Pattern
+}
+
+func New(config Config) (*Filter, error) {
+	allowNames, err := pattern.CompileAll(config.AllowNames)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.AllowNames: %s", config, err)
+	}
+	allowOwners, err := pattern.CompileAll(config.AllowOwners)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.AllowOwners: %s", config, err)
+	}
+	denyNames, err := pattern.CompileAll(config.DenyNames)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.DenyNames: %s", config, err)
+	}
+	denyOwners, err := pattern.CompileAll(config.DenyOwners)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.DenyOwners: %s", config, err)
+	}
+
+	f := &Filter{
+		allowNames:  allowNames,
+		allowOwners: allowOwners,
+		denyNames:   denyNames,
+		denyOwners:  denyOwners,
+	}
+
+	return f, nil
+}
+
+// Ignore returns whether deployments of the given repository are ignored and
+// the reason why.
+func (f *Filter) Ignore(owner, name string) (string, bool) {
+	if p, ok := pattern.MatchAny(f.denyOwners, owner); ok {
+		return fmt.Sprintf("repository owner %#q matches deny pattern %#q", owner, p), true
+	}
+	if p, ok := pattern.MatchAny(f.denyNames, name); ok {
+		return fmt.Sprintf("repository name %#q matches deny pattern %#q", name, p), true
+	}
+	if len(f.allowOwners) > 0 {
+		if _, ok := pattern.MatchAny(f.allowOwners, owner); !ok {
+			return fmt.Sprintf("repository owner %#q matches no allow pattern", owner), true
+		}
+	}
+	if len(f.allowNames) > 0 {
+		if _, ok := pattern.MatchAny(f.allowNames, name); !ok {
+			return fmt.Sprintf("repository name %#q matches no allow pattern", name), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,304 @@
+// Package gc periodically removes App CRs of branches which got deleted or
+// were not deployed to for a configurable time.
+package gc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/apiextensions/v3/pkg/apis/application/v1alpha1"
+	"github.com/giantswarm/k8sclient/v5/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-github/v32/github"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/app-checker/pkg/annotation"
+	"github.com/giantswarm/app-checker/pkg/githubapp"
+	"github.com/giantswarm/app-checker/pkg/label"
+	"github.com/giantswarm/app-checker/service/deployer"
+	"github.com/giantswarm/app-checker/service/worker"
+)
+
+type Config struct {
+	Deployer     *deployer.Deployer
+	GithubClient *github.Client
+	K8sClient    k8sclient.Interface
+	Logger       micrologger.Logger
+	Worker       *worker.Pool
+
+	// DryRun only logs the App CRs which would be removed.
+	DryRun bool
+	// Interval is the interval in which stale App CRs are collected.
+	Interval time.Duration
+	// TTL is the time after the last deployment of a ref other than the
+	// default branch after which its App CRs are removed. App CRs of deleted
+	// refs are removed regardless. Zero disables removing App CRs of existing
+	// refs.
+	TTL time.Duration
+}
+
+type Collector struct {
+	deployer     *deployer.Deployer
+	githubClient *github.Client
+	k8sClient    k8sclient.Interface
+	logger       micrologger.Logger
+	worker       *worker.Pool
+
+	dryRun   bool
+	interval time.Duration
+	ttl      time.Duration
+
+	bootOnce     sync.Once
+	shutdownOnce sync.Once
+	stop         chan struct{}
+}
+
+func New(config Config) (*Collector, error) {
+	if config.Deployer == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Deployer must not be empty", config)
+	}
+	if config.GithubClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GithubClient must not be empty", config)
+	}
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Worker == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Worker must not be empty", config)
+	}
+
+	if config.Interval <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Interval must be greater than zero", config)
+	}
+	if config.TTL < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.TTL must not be negative", config)
+	}
+
+	c := &Collector{
+		deployer:     config.Deployer,
+		githubClient: config.GithubClient,
+		k8sClient:    config.K8sClient,
+		logger:       config.Logger,
+		worker:       config.Worker,
+
+		dryRun:   config.DryRun,
+		interval: config.Interval,
+		ttl:      config.TTL,
+
+		stop: make(chan struct{}),
+	}
+
+	return c, nil
+}
+
+// Boot starts collecting stale App CRs periodically.
+func (c *Collector) Boot() {
+	c.bootOnce.Do(func() {
+		go c.collectLoop()
+	})
+}
+
+// Shutdown stops collecting stale App CRs.
+func (c *Collector) Shutdown() {
+	c.shutdownOnce.Do(func() {
+		close(c.stop)
+	})
+}
+
+func (c *Collector) collectLoop() {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				select {
+				case <-c.stop:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+
+			err := c.collect(ctx)
+			cancel()
+			if err != nil {
+				c.logger.Log("level", "error", "message", "failed to collect stale App CRs", "stack", fmt.Sprintf("%#v", err))
+			}
+
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// collect removes all App CRs managed by app-checker which are stale.
+func (c *Collector) collect(ctx context.Context) error {
+	lo := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", label.ManagedBy, label.ManagedByValue()),
+	}
+
+	apps, err := c.k8sClient.G8sClient().ApplicationV1alpha1().Apps(metav1.NamespaceAll).List(ctx, lo)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// defaultBranches caches the default branch of every repository for the
+	// duration of a single run.
+	defaultBranches := map[string]string{}
+
+	for i := range apps.Items {
+		cr := &apps.Items[i]
+
+		if cr.GetDeletionTimestamp() != nil || cr.Annotations[annotation.Ref] == "" {
+			continue
+		}
+
+		err = c.collectApp(ctx, cr, defaultBranches)
+		if ctx.Err() != nil {
+			return microerror.Mask(ctx.Err())
+		} else if err != nil {
+			c.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to collect App CR %#q in namespace %#q", cr.GetName(), cr.GetNamespace()), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	return nil
+}
+
+// collectApp removes the given App CR when its ref was deleted or not
+// deployed to within the TTL.
+func (c *Collector) collectApp(ctx context.Context, cr *v1alpha1.App, defaultBranches map[string]string) error {
+	ref := cr.Annotations[annotation.Ref]
+
+	owner, repository := splitRepository(cr.Annotations[annotation.Repository])
+	if owner == "" || repository == "" {
+		return nil
+	}
+
+	var installation *github.Installation
+	if id, err := strconv.ParseInt(cr.Annotations[annotation.InstallationID], 10, 64); err == nil && id != 0 {
+		ctx = githubapp.WithInstallationID(ctx, id)
+		installation = &github.Installation{ID: &id}
+	}
+
+	exists, err := c.refExists(ctx, owner, repository, ref)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	latest, err := c.deployer.LatestDeployment(ctx, owner, repository, ref, cr.GetNamespace(), cr.GetName())
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var reason string
+	if !exists {
+		reason = fmt.Sprintf("ref %#q does not exist anymore", ref)
+	} else if c.ttl > 0 {
+		defaultBranch, ok := defaultBranches[owner+"/"+repository]
+		if !ok {
+			repo, _, err := c.githubClient.Repositories.Get(ctx, owner, repository)
+			if err != nil {
+				return microerror.Mask(err)
+			}
+
+			defaultBranch = repo.GetDefaultBranch()
+			defaultBranches[owner+"/"+repository] = defaultBranch
+		}
+
+		lastDeployed := lastDeployedAt(cr)
+		if latest != nil {
+			lastDeployed = latest.GetCreatedAt().Time
+		}
+
+		if ref != defaultBranch && time.Since(lastDeployed) > c.ttl {
+			reason = fmt.Sprintf("ref %#q was last deployed %s ago", ref, time.Since(lastDeployed).Round(time.Minute))
+		}
+	}
+
+	if reason == "" {
+		return nil
+	}
+
+	if c.dryRun {
+		c.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("would remove App CR %#q in namespace %#q, %s", cr.GetName(), cr.GetNamespace(), reason))
+		return nil
+	}
+
+	// App CRs are removed through their latest deployment, which gets the
+	// outcome reported and serializes the removal with other deployments of
+	// the App CR. Without deployment the App CR is deleted right away.
+	if latest != nil {
+		repo := &github.Repository{
+			FullName: github.String(owner + "/" + repository),
+			Name:     github.String(repository),
+			Owner: &github.User{
+				Login: github.String(owner),
+			},
+		}
+
+		err = c.worker.Enqueue(ctx, deployer.NewRemovalEvent(latest, repo, installation))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		c.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("queued removal of App CR %#q in namespace %#q through deployment %d, %s", cr.GetName(), cr.GetNamespace(), latest.GetID(), reason))
+
+		return nil
+	}
+
+	err = c.k8sClient.G8sClient().ApplicationV1alpha1().Apps(cr.GetNamespace()).Delete(ctx, cr.GetName(), metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	c.logger.LogCtx(ctx, "level", "info", "message", fmt.Sprintf("deleted App CR %#q in namespace %#q, %s", cr.GetName(), cr.GetNamespace(), reason))
+
+	return nil
+}
+
+// lastDeployedAt returns when app-checker last created or updated the given
+// App CR. App CRs without valid annotation are aged by their creation.
+func lastDeployedAt(cr *v1alpha1.App) time.Time {
+	t, err := time.Parse(time.RFC3339, cr.Annotations[annotation.DeployedAt])
+	if err != nil {
+		return cr.GetCreationTimestamp().Time
+	}
+
+	return t
+}
+
+// refExists returns whether the given ref still resolves to a commit of the
+// repository.
+func (c *Collector) refExists(ctx context.Context, owner, repository, ref string) (bool, error) {
+	_, res, err := c.githubClient.Repositories.GetCommitSHA1(ctx, owner, repository, ref, "")
+	if res != nil && (res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusUnprocessableEntity) {
+		return false, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return true, nil
+}
+
+func splitRepository(fullName string) (string, string) {
+	i := strings.Index(fullName, "/")
+	if i < 0 {
+		return "", ""
+	}
+
+	return fullName[:i], fullName[i+1:]
+}
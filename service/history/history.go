@@ -0,0 +1,355 @@
+// Package history records every processed GitHub deployment as AppDeployment
+// CR so the deployment history of an installation can be inspected with
+// kubectl.
+package history
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/k8sclient/v5/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/giantswarm/app-checker/pkg/apis/appchecker/v1alpha1"
+	"github.com/giantswarm/app-checker/pkg/deploymentstate"
+	"github.com/giantswarm/app-checker/pkg/label"
+	"github.com/giantswarm/app-checker/service/tracker"
+)
+
+const (
+	// maxTransitions bounds the status transitions kept per AppDeployment
+	// CR. The oldest transitions are dropped first.
+	maxTransitions = 50
+	// pruneInterval is the interval in which AppDeployment CRs older than the
+	// retention are deleted.
+	pruneInterval = 1 * time.Hour
+)
+
+type Config struct {
+	K8sClient k8sclient.Interface
+	Logger    micrologger.Logger
+
+	Environment string
+	Namespace   string
+	// Retention is the time AppDeployment CRs are kept after their deployment
+	// finished. Zero keeps them forever.
+	Retention time.Duration
+}
+
+type Recorder struct {
+	k8sClient k8sclient.Interface
+	logger    micrologger.Logger
+
+	environment string
+	namespace   string
+	retention   time.Duration
+
+	bootOnce     sync.Once
+	shutdownOnce sync.Once
+	stop         chan struct{}
+}
+
+func New(config Config) (*Recorder, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.Environment == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Environment must not be empty", config)
+	}
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Namespace must not be empty", config)
+	}
+	if config.Retention < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Retention must not be negative", config)
+	}
+
+	r := &Recorder{
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+
+		environment: config.Environment,
+		namespace:   config.Namespace,
+		retention:   config.Retention,
+
+		stop: make(chan struct{}),
+	}
+
+	return r, nil
+}
+
+// Boot starts pruning AppDeployment CRs older than the retention
+// periodically.
+func (r *Recorder) Boot() {
+	if r.retention == 0 {
+		return
+	}
+
+	r.bootOnce.Do(func() {
+		go r.pruneLoop()
+	})
+}
+
+// Shutdown stops pruning AppDeployment CRs.
+func (r *Recorder) Shutdown() {
+	r.shutdownOnce.Do(func() {
+		close(r.stop)
+	})
+}
+
+// Start creates the AppDeployment CR of the given deployment. An already
+// existing CR, e.g. of a deployment resumed after a restart, is kept.
+func (r *Recorder) Start(ctx context.Context, d tracker.Deployment) error {
+	cr := v1alpha1.NewAppDeploymentCR()
+	cr.ObjectMeta = metav1.ObjectMeta{
+		Name:      Name(d.Repository, d.ID),
+		Namespace: r.namespace,
+		Labels: map[string]string{
+			label.ManagedBy: label.ManagedByValue(),
+		},
+	}
+	if len(validation.IsValidLabelValue(d.Repository)) == 0 {
+		cr.Labels[label.Repository] = d.Repository
+	}
+	cr.Spec = v1alpha1.AppDeploymentSpec{
+		App: v1alpha1.AppDeploymentSpecApp{
+			Catalog:   d.App.Catalog,
+			Cluster:   d.App.Cluster,
+			Name:      d.App.Name,
+			Namespace: d.App.Namespace,
+			Version:   d.App.Version,
+		},
+		DeploymentID:   d.ID,
+		Environment:    r.environment,
+		InstallationID: d.InstallationID,
+		Ref:            d.Ref,
+		Repository: v1alpha1.AppDeploymentSpecRepository{
+			Name:  d.Repository,
+			Owner: d.Owner,
+		},
+		SHA: d.SHA,
+	}
+
+	err := r.k8sClient.CtrlClient().Create(ctx, cr)
+	if apierrors.IsAlreadyExists(err) {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("AppDeployment CR %#q exists already", cr.Name))
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("created AppDeployment CR %#q", cr.Name))
+
+	return nil
+}
+
+// AddStatus records a GitHub deployment status reported for the given
+// deployment. Statuses of deployments without AppDeployment CR, e.g. ones
+// processed before history was recorded, are ignored.
+func (r *Recorder) AddStatus(ctx context.Context, repository string, id int64, state, description string) error {
+	key := types.NamespacedName{
+		Name:      Name(repository, id),
+		Namespace: r.namespace,
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cr := &v1alpha1.AppDeployment{}
+
+		err := r.k8sClient.CtrlClient().Get(ctx, key, cr)
+		if err != nil {
+			return err
+		}
+
+		now := metav1.NewTime(time.Now())
+
+		cr.Status.State = state
+		cr.Status.Description = description
+		if deploymentstate.IsFinal(state) {
+			cr.Status.FinishedAt = &now
+		}
+
+		cr.Status.Transitions = append(cr.Status.Transitions, v1alpha1.AppDeploymentStatusTransition{
+			Description: description,
+			State:       state,
+			Time:        now,
+		})
+		if len(cr.Status.Transitions) > maxTransitions {
+			cr.Status.Transitions = cr.Status.Transitions[len(cr.Status.Transitions)-maxTransitions:]
+		}
+
+		return r.k8sClient.CtrlClient().Status().Update(ctx, cr)
+	})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Find returns the AppDeployment CR of the GitHub deployment with the given
+// ID of the given repository, or nil when there is none.
+func (r *Recorder) Find(ctx context.Context, repository string, id int64) (*v1alpha1.AppDeployment, error) {
+	key := types.NamespacedName{
+		Name:      Name(repository, id),
+		Namespace: r.namespace,
+	}
+
+	cr := &v1alpha1.AppDeployment{}
+
+	err := r.k8sClient.CtrlClient().Get(ctx, key, cr)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if cr.Spec.Environment != r.environment || cr.Spec.DeploymentID != id {
+		return nil, nil
+	}
+
+	return cr, nil
+}
+
+// List returns all AppDeployment CRs of the environment, newest deployment
+// first. Deployments are ordered by creation, as manual deployments without
+// GitHub deployment have negative IDs.
+func (r *Recorder) List(ctx context.Context) ([]v1alpha1.AppDeployment, error) {
+	list := &v1alpha1.AppDeploymentList{}
+
+	err := r.k8sClient.CtrlClient().List(ctx, list, client.InNamespace(r.namespace))
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var deployments []v1alpha1.AppDeployment
+	for _, cr := range list.Items {
+		if cr.Spec.Environment != r.environment {
+			continue
+		}
+
+		deployments = append(deployments, cr)
+	}
+
+	sort.Slice(deployments, func(i, j int) bool {
+		ti := deployments[i].CreationTimestamp
+		tj := deployments[j].CreationTimestamp
+		if !ti.Equal(&tj) {
+			return tj.Before(&ti)
+		}
+
+		return deployments[i].Spec.DeploymentID > deployments[j].Spec.DeploymentID
+	})
+
+	return deployments, nil
+}
+
+// Status returns the latest status recorded for the given deployment, or empty
+// strings when none is recorded.
+func (r *Recorder) Status(ctx context.Context, repository string, id int64) (string, string, error) {
+	key := types.NamespacedName{
+		Name:      Name(repository, id),
+		Namespace: r.namespace,
+	}
+
+	cr := &v1alpha1.AppDeployment{}
+
+	err := r.k8sClient.CtrlClient().Get(ctx, key, cr)
+	if apierrors.IsNotFound(err) {
+		return "", "", nil
+	} else if err != nil {
+		return "", "", microerror.Mask(err)
+	}
+
+	return cr.Status.State, cr.Status.Description, nil
+}
+
+func (r *Recorder) pruneLoop() {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				select {
+				case <-r.stop:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+
+			err := r.prune(ctx, time.Now())
+			cancel()
+			if err != nil {
+				r.logger.Log("level", "error", "message", "failed to prune AppDeployment CRs", "stack", fmt.Sprintf("%#v", err))
+			}
+
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// prune deletes the AppDeployment CRs of the environment whose deployment
+// finished longer than the retention ago. Deployments which never finished,
+// e.g. ones interrupted for good, are aged by their creation.
+func (r *Recorder) prune(ctx context.Context, now time.Time) error {
+	deployments, err := r.List(ctx)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	var pruned int
+	for i := range deployments {
+		cr := &deployments[i]
+
+		age := cr.GetCreationTimestamp().Time
+		if cr.Status.FinishedAt != nil {
+			age = cr.Status.FinishedAt.Time
+		}
+		if now.Sub(age) < r.retention {
+			continue
+		}
+
+		err = r.k8sClient.CtrlClient().Delete(ctx, cr)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return microerror.Mask(err)
+		}
+
+		pruned++
+	}
+
+	if pruned > 0 {
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("pruned %d AppDeployment CRs older than %s", pruned, r.retention))
+	}
+
+	return nil
+}
+
+// Name returns the name of the AppDeployment CR of the GitHub deployment with
+// the given ID.
+func Name(repository string, id int64) string {
+	name := strings.ToLower(repository)
+	name = strings.NewReplacer("_", "-", ".", "-").Replace(name)
+
+	return fmt.Sprintf("%s-%d", name, id)
+}
@@ -0,0 +1,312 @@
+// Package reporter creates GitHub deployment statuses. Transient failures are
+// retried with jittered exponential backoff honouring GitHub rate limits, and
+// statuses which could not be created are re-sent in the background. Statuses
+// waiting to be re-sent are only kept in memory and are lost on restart.
+package reporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-github/v32/github"
+
+	"github.com/giantswarm/app-checker/pkg/githubapp"
+)
+
+const (
+	initialInterval = 1 * time.Second
+	maxInterval     = 30 * time.Second
+	// maxUnreportedAge is the time after which statuses which could not be
+	// created are given up.
+	maxUnreportedAge = 24 * time.Hour
+)
+
+type Config struct {
+	GithubClient *github.Client
+	Logger       micrologger.Logger
+
+	// MaxAttempts bounds the attempts to create a deployment status per
+	// report.
+	MaxAttempts int
+	// ResendInterval is the interval in which statuses which could not be
+	// created are re-sent.
+	ResendInterval time.Duration
+	// RetryBudget bounds the time spent retrying a deployment status per
+	// report.
+	RetryBudget time.Duration
+}
+
+type Reporter struct {
+	githubClient *github.Client
+	logger       micrologger.Logger
+
+	maxAttempts    int
+	resendInterval time.Duration
+	retryBudget    time.Duration
+
+	bootOnce     sync.Once
+	mutex        sync.Mutex
+	random       *rand.Rand
+	randomMutex  sync.Mutex
+	shutdownOnce sync.Once
+	stop         chan struct{}
+	unreported   map[int64]*status
+}
+
+// status is a deployment status which could not be created yet.
+type status struct {
+	installationID int64
+	owner          string
+	repository     string
+	request        github.DeploymentStatusRequest
+	since          time.Time
+}
+
+func New(config Config) (*Reporter, error) {
+	if config.GithubClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GithubClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.MaxAttempts <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.MaxAttempts must be greater than zero", config)
+	}
+	if config.ResendInterval <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ResendInterval must be greater than zero", config)
+	}
+	if config.RetryBudget < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.RetryBudget must not be negative", config)
+	}
+
+	r := &Reporter{
+		githubClient: config.GithubClient,
+		logger:       config.Logger,
+
+		maxAttempts:    config.MaxAttempts,
+		resendInterval: config.ResendInterval,
+		retryBudget:    config.RetryBudget,
+
+		random:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		stop:       make(chan struct{}),
+		unreported: map[int64]*status{},
+	}
+
+	return r, nil
+}
+
+// Boot starts re-sending statuses which could not be created.
+func (r *Reporter) Boot() {
+	r.bootOnce.Do(func() {
+		go r.resendLoop()
+	})
+}
+
+// Shutdown stops re-sending statuses which could not be created.
+func (r *Reporter) Shutdown() {
+	r.shutdownOnce.Do(func() {
+		close(r.stop)
+	})
+}
+
+// Report creates the given status of the GitHub deployment with the given ID.
+// When it cannot be created because of transient failures, it is re-sent in
+// the background unless a newer status of the deployment gets reported first.
+// Any older status of the deployment waiting to be re-sent is dropped, so it
+// is never sent over the given one.
+func (r *Reporter) Report(ctx context.Context, owner, repository string, id int64, request github.DeploymentStatusRequest) error {
+	s := &status{
+		installationID: githubapp.InstallationID(ctx),
+		owner:          owner,
+		repository:     repository,
+		request:        request,
+		since:          time.Now(),
+	}
+
+	retryable, err := r.create(ctx, id, s)
+
+	r.mutex.Lock()
+	if retryable {
+		r.unreported[id] = s
+	} else {
+		delete(r.unreported, id)
+	}
+	r.mutex.Unlock()
+
+	if retryable {
+		r.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed to create status %#q of deployment %d, re-sending it in the background", request.GetState(), id))
+	}
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// create creates the given status, retrying transient failures within the
+// retry budget. It returns whether a returned error is transient.
+func (r *Reporter) create(ctx context.Context, id int64, s *status) (bool, error) {
+	deadline := time.Now().Add(r.retryBudget)
+
+	for attempt := 0; ; attempt++ {
+		request := s.request
+
+		_, res, err := r.githubClient.Repositories.CreateDeploymentStatus(ctx, s.owner, s.repository, id, &request)
+		if err == nil {
+			return false, nil
+		}
+		if ctx.Err() != nil {
+			return false, microerror.Mask(ctx.Err())
+		}
+
+		wait, retryable := r.backoff(res, err, attempt)
+		if !retryable {
+			return false, microerror.Mask(err)
+		}
+		if attempt+1 >= r.maxAttempts || time.Now().Add(wait).After(deadline) {
+			return true, microerror.Mask(err)
+		}
+
+		r.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("retrying status %#q of deployment %d in %s", request.GetState(), id, wait), "stack", fmt.Sprintf("%#v", err))
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return false, microerror.Mask(ctx.Err())
+		}
+	}
+}
+
+func (r *Reporter) resendLoop() {
+	ticker := time.NewTicker(r.resendInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.resend()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// resend re-sends the latest unreported status of every deployment.
+func (r *Reporter) resend() {
+	r.mutex.Lock()
+	unreported := make(map[int64]*status, len(r.unreported))
+	for id, s := range r.unreported {
+		unreported[id] = s
+	}
+	r.mutex.Unlock()
+
+	for id, s := range unreported {
+		if time.Since(s.since) > maxUnreportedAge {
+			r.logger.Log("level", "warning", "message", fmt.Sprintf("giving up status %#q of deployment %d after %s", s.request.GetState(), id, maxUnreportedAge))
+			r.forget(id, s)
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-r.stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		if s.installationID != 0 {
+			ctx = githubapp.WithInstallationID(ctx, s.installationID)
+		}
+
+		retryable, err := r.create(ctx, id, s)
+		cancel()
+		if retryable {
+			continue
+		} else if err != nil {
+			r.logger.Log("level", "error", "message", fmt.Sprintf("failed to re-send status %#q of deployment %d", s.request.GetState(), id), "stack", fmt.Sprintf("%#v", err))
+		} else {
+			r.logger.Log("level", "debug", "message", fmt.Sprintf("re-sent status %#q of deployment %d", s.request.GetState(), id))
+		}
+
+		r.forget(id, s)
+	}
+}
+
+// forget drops the given unreported status unless a newer one replaced it in
+// the meantime.
+func (r *Reporter) forget(id int64, s *status) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.unreported[id] == s {
+		delete(r.unreported, id)
+	}
+}
+
+// backoff returns the time to wait before retrying a request which failed
+// with the given response and error, and whether it should be retried at all.
+// GitHub rate limits and Retry-After headers take precedence over the
+// jittered exponential backoff.
+func (r *Reporter) backoff(res *github.Response, err error, attempt int) (time.Duration, bool) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return 0, false
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		if abuseErr.RetryAfter != nil {
+			return *abuseErr.RetryAfter, true
+		}
+
+		return r.jitter(attempt), true
+	}
+
+	var rateLimitErr *github.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return time.Until(rateLimitErr.Rate.Reset.Time), true
+	}
+
+	if res == nil {
+		// The request did not get any response, e.g. because of network
+		// failures.
+		return r.jitter(attempt), true
+	}
+
+	if res.StatusCode != http.StatusTooManyRequests && res.StatusCode < http.StatusInternalServerError {
+		return 0, false
+	}
+
+	seconds, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	return r.jitter(attempt), true
+}
+
+// jitter returns a random duration up to the exponentially growing interval
+// of the given attempt.
+func (r *Reporter) jitter(attempt int) time.Duration {
+	interval := maxInterval
+	if attempt < 5 {
+		interval = initialInterval << uint(attempt)
+	}
+	if interval > maxInterval {
+		interval = maxInterval
+	}
+
+	r.randomMutex.Lock()
+	defer r.randomMutex.Unlock()
+
+	return time.Duration(r.random.Int63n(int64(interval)))
+}
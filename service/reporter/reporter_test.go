@@ -0,0 +1,201 @@
+package reporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v32/github"
+)
+
+func Test_Reporter_backoff(t *testing.T) {
+	retryAfter := 42 * time.Second
+
+	testCases := []struct {
+		name    string
+		res     *github.Response
+		err     error
+		attempt int
+		// expectedMin and expectedMax bound the returned wait time.
+		expectedMin       time.Duration
+		expectedMax       time.Duration
+		expectedRetryable bool
+	}{
+		{
+			name:              "case 0: cancelled context not retried",
+			err:               context.Canceled,
+			expectedRetryable: false,
+		},
+		{
+			name:              "case 1: wrapped deadline not retried",
+			err:               fmt.Errorf("request failed: %w", context.DeadlineExceeded),
+			expectedRetryable: false,
+		},
+		{
+			name:              "case 2: abuse rate limit waits for Retry-After",
+			res:               testResponse(http.StatusForbidden, ""),
+			err:               &github.AbuseRateLimitError{RetryAfter: &retryAfter},
+			expectedMin:       retryAfter,
+			expectedMax:       retryAfter,
+			expectedRetryable: true,
+		},
+		{
+			name:              "case 3: abuse rate limit without Retry-After jittered",
+			res:               testResponse(http.StatusForbidden, ""),
+			err:               &github.AbuseRateLimitError{},
+			attempt:           2,
+			expectedMin:       0,
+			expectedMax:       4 * time.Second,
+			expectedRetryable: true,
+		},
+		{
+			name: "case 4: rate limit waits for the reset",
+			res:  testResponse(http.StatusForbidden, ""),
+			err: &github.RateLimitError{
+				Rate: github.Rate{
+					Reset: github.Timestamp{Time: time.Now().Add(time.Minute)},
+				},
+			},
+			expectedMin:       time.Minute - 5*time.Second,
+			expectedMax:       time.Minute,
+			expectedRetryable: true,
+		},
+		{
+			name:              "case 5: network failure jittered",
+			err:               errors.New("connection refused"),
+			attempt:           1,
+			expectedMin:       0,
+			expectedMax:       2 * time.Second,
+			expectedRetryable: true,
+		},
+		{
+			name:              "case 6: client error not retried",
+			res:               testResponse(http.StatusUnprocessableEntity, ""),
+			err:               errors.New("validation failed"),
+			expectedRetryable: false,
+		},
+		{
+			name:              "case 7: not found not retried",
+			res:               testResponse(http.StatusNotFound, ""),
+			err:               errors.New("not found"),
+			expectedRetryable: false,
+		},
+		{
+			name:              "case 8: too many requests waits for Retry-After",
+			res:               testResponse(http.StatusTooManyRequests, "7"),
+			err:               errors.New("too many requests"),
+			expectedMin:       7 * time.Second,
+			expectedMax:       7 * time.Second,
+			expectedRetryable: true,
+		},
+		{
+			name:              "case 9: server error jittered",
+			res:               testResponse(http.StatusBadGateway, ""),
+			err:               errors.New("bad gateway"),
+			attempt:           3,
+			expectedMin:       0,
+			expectedMax:       8 * time.Second,
+			expectedRetryable: true,
+		},
+		{
+			name:              "case 10: server error with invalid Retry-After jittered",
+			res:               testResponse(http.StatusServiceUnavailable, "soon"),
+			err:               errors.New("service unavailable"),
+			attempt:           10,
+			expectedMin:       0,
+			expectedMax:       maxInterval,
+			expectedRetryable: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := testReporter()
+
+			wait, retryable := r.backoff(tc.res, tc.err, tc.attempt)
+			if retryable != tc.expectedRetryable {
+				t.Fatalf("retryable == %t, want %t", retryable, tc.expectedRetryable)
+			}
+			if wait < tc.expectedMin || wait > tc.expectedMax {
+				t.Fatalf("wait == %s, want between %s and %s", wait, tc.expectedMin, tc.expectedMax)
+			}
+		})
+	}
+}
+
+func Test_Reporter_jitter(t *testing.T) {
+	testCases := []struct {
+		name    string
+		attempt int
+		// expectedMax is the exclusive upper bound of the returned duration.
+		expectedMax time.Duration
+	}{
+		{
+			name:        "case 0: first attempt",
+			attempt:     0,
+			expectedMax: initialInterval,
+		},
+		{
+			name:        "case 1: interval doubled per attempt",
+			attempt:     3,
+			expectedMax: 8 * initialInterval,
+		},
+		{
+			name:        "case 2: interval capped",
+			attempt:     5,
+			expectedMax: maxInterval,
+		},
+		{
+			name:        "case 3: interval capped without overflowing",
+			attempt:     100,
+			expectedMax: maxInterval,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := testReporter()
+
+			var max time.Duration
+			for i := 0; i < 1000; i++ {
+				wait := r.jitter(tc.attempt)
+				if wait < 0 || wait >= tc.expectedMax {
+					t.Fatalf("wait == %s, want between 0 and %s", wait, tc.expectedMax)
+				}
+				if wait > max {
+					max = wait
+				}
+			}
+
+			// The waits are spread across the whole interval instead of
+			// growing with the attempt only.
+			if max < tc.expectedMax/2 {
+				t.Fatalf("max wait == %s, want at least %s", max, tc.expectedMax/2)
+			}
+		})
+	}
+}
+
+func testReporter() *Reporter {
+	return &Reporter{
+		random: rand.New(rand.NewSource(1)),
+	}
+}
+
+func testResponse(statusCode int, retryAfter string) *github.Response {
+	header := http.Header{}
+	if retryAfter != "" {
+		header.Set("Retry-After", retryAfter)
+	}
+
+	return &github.Response{
+		Response: &http.Response{
+			StatusCode: statusCode,
+			Header:     header,
+		},
+	}
+}
@@ -3,25 +3,60 @@
 package service
 
 import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/giantswarm/k8sclient/v5/pkg/k8sclient"
 	"github.com/giantswarm/microendpoint/service/version"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
+	"github.com/google/go-github/v32/github"
 	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
 
 	"github.com/giantswarm/app-checker/flag"
+	"github.com/giantswarm/app-checker/pkg/apimetrics"
+	"github.com/giantswarm/app-checker/pkg/githubapp"
 	"github.com/giantswarm/app-checker/pkg/project"
+	"github.com/giantswarm/app-checker/service/appwatcher"
+	"github.com/giantswarm/app-checker/service/catalog"
+	"github.com/giantswarm/app-checker/service/catalog/index"
+	"github.com/giantswarm/app-checker/service/dedupe"
+	dedupeconfigmap "github.com/giantswarm/app-checker/service/dedupe/configmap"
+	"github.com/giantswarm/app-checker/service/deliveries"
+	"github.com/giantswarm/app-checker/service/deployer"
+	"github.com/giantswarm/app-checker/service/filter"
+	"github.com/giantswarm/app-checker/service/gc"
+	"github.com/giantswarm/app-checker/service/history"
+	"github.com/giantswarm/app-checker/service/reporter"
+	"github.com/giantswarm/app-checker/service/store/configmap"
+	"github.com/giantswarm/app-checker/service/tracker"
+	"github.com/giantswarm/app-checker/service/worker"
 )
 
 // Config represents the configuration used to create a new service.
 type Config struct {
-	Logger micrologger.Logger
+	K8sClient k8sclient.Interface
+	Logger    micrologger.Logger
 
 	Flag  *flag.Flag
 	Viper *viper.Viper
 }
 
 type Service struct {
-	Version *version.Service
+	AppWatcher *appwatcher.Watcher
+	Dedupe     *dedupe.Cache
+	Deliveries *deliveries.Recorder
+	Deployer   *deployer.Deployer
+	Filter     *filter.Filter
+	GC         *gc.Collector
+	History    *history.Recorder
+	Reporter   *reporter.Reporter
+	Tracker    *tracker.Tracker
+	Version    *version.Service
+	Worker     *worker.Pool
 }
 
 // New creates a new configured service object.
@@ -34,12 +69,236 @@ func New(config Config) (*Service, error) {
 	}
 
 	// Dependencies.
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "k8sClient must not be empty")
+	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "logger must not be empty")
 	}
 
 	var err error
 
+	var githubClient *github.Client
+	if appID := config.Viper.GetInt64(config.Flag.Service.Github.App.ID); appID != 0 {
+		privateKeyFile := config.Viper.GetString(config.Flag.Service.Github.App.PrivateKeyFile)
+		if privateKeyFile == "" {
+			return nil, microerror.Maskf(invalidConfigError, "%#q must not be empty", config.Flag.Service.Github.App.PrivateKeyFile)
+		}
+
+		privateKey, err := ioutil.ReadFile(privateKeyFile)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		c := githubapp.Config{
+			AppID:          appID,
+			InstallationID: config.Viper.GetInt64(config.Flag.Service.Github.App.InstallationID),
+			PrivateKey:     privateKey,
+		}
+
+		transport, err := githubapp.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		githubClient = github.NewClient(&http.Client{Transport: apimetrics.NewGithubTransport(transport)})
+	} else {
+		githubToken := config.Viper.GetString(config.Flag.Service.Github.GitHubToken)
+		if githubToken == "" {
+			return nil, microerror.Maskf(invalidConfigError, "%#q or %#q must not be empty", config.Flag.Service.Github.GitHubToken, config.Flag.Service.Github.App.ID)
+		}
+
+		ctx := context.Background()
+		ts := oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: githubToken},
+		)
+		tc := oauth2.NewClient(ctx, ts)
+		tc.Transport = apimetrics.NewGithubTransport(tc.Transport)
+
+		githubClient = github.NewClient(tc)
+	}
+
+	var catalogRouter *catalog.Router
+	{
+		rules := catalog.DefaultRules()
+
+		raw := config.Viper.GetString(config.Flag.Service.Catalog.Rules)
+		if raw != "" {
+			rules, err = catalog.ParseRules(raw)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		c := catalog.Config{
+			Rules: rules,
+		}
+
+		catalogRouter, err = catalog.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var catalogIndex *index.Index
+	if config.Viper.GetBool(config.Flag.Service.Catalog.Validation.Enabled) {
+		c := index.Config{
+			HTTPClient: &http.Client{Timeout: 30 * time.Second},
+			K8sClient:  config.K8sClient,
+			Logger:     config.Logger,
+
+			Wait: config.Viper.GetDuration(config.Flag.Service.Catalog.Validation.Wait),
+		}
+
+		catalogIndex, err = index.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var appWatcher *appwatcher.Watcher
+	{
+		c := appwatcher.Config{
+			K8sClient: config.K8sClient,
+			Logger:    config.Logger,
+
+			ResyncPeriod: 5 * time.Minute,
+		}
+
+		appWatcher, err = appwatcher.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var deploymentTracker *tracker.Tracker
+	{
+		c := tracker.Config{}
+
+		deploymentTracker, err = tracker.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var deliveryRecorder *deliveries.Recorder
+	{
+		c := deliveries.Config{}
+
+		deliveryRecorder, err = deliveries.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var dedupeBackend dedupe.Backend
+	if config.Viper.GetBool(config.Flag.Service.Dedupe.Persistent) {
+		c := dedupeconfigmap.Config{
+			K8sClient: config.K8sClient,
+			Logger:    config.Logger,
+
+			Namespace: config.Viper.GetString(config.Flag.Service.Store.Namespace),
+		}
+
+		dedupeBackend, err = dedupeconfigmap.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var dedupeCache *dedupe.Cache
+	{
+		c := dedupe.Config{
+			Backend: dedupeBackend,
+			Logger:  config.Logger,
+
+			TTL: config.Viper.GetDuration(config.Flag.Service.Dedupe.TTL),
+		}
+
+		dedupeCache, err = dedupe.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var statusReporter *reporter.Reporter
+	{
+		c := reporter.Config{
+			GithubClient: githubClient,
+			Logger:       config.Logger,
+
+			MaxAttempts:    config.Viper.GetInt(config.Flag.Service.Github.Retry.MaxAttempts),
+			ResendInterval: config.Viper.GetDuration(config.Flag.Service.Github.Retry.ResendInterval),
+			RetryBudget:    config.Viper.GetDuration(config.Flag.Service.Github.Retry.Budget),
+		}
+
+		statusReporter, err = reporter.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var historyRecorder *history.Recorder
+	{
+		c := history.Config{
+			K8sClient: config.K8sClient,
+			Logger:    config.Logger,
+
+			Environment: config.Viper.GetString(config.Flag.Service.Installation.Environment),
+			Namespace:   config.Viper.GetString(config.Flag.Service.Store.Namespace),
+			Retention:   config.Viper.GetDuration(config.Flag.Service.History.Retention),
+		}
+
+		historyRecorder, err = history.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var deployerService *deployer.Deployer
+	{
+		c := deployer.Config{
+			AppWatcher:    appWatcher,
+			CatalogIndex:  catalogIndex,
+			CatalogRouter: catalogRouter,
+			GithubClient:  githubClient,
+			History:       historyRecorder,
+			K8sClient:     config.K8sClient,
+			Logger:        config.Logger,
+			Reporter:      statusReporter,
+			Tracker:       deploymentTracker,
+
+			AutoInactive:           config.Viper.GetBool(config.Flag.Service.Github.AutoInactive),
+			DefaultTimeout:         config.Viper.GetDuration(config.Flag.Service.Deployer.Timeout),
+			DryRun:                 config.Viper.GetBool(config.Flag.Service.DryRun),
+			Env:                    config.Viper.GetString(config.Flag.Service.Installation.Environment),
+			EnvironmentURLTemplate: config.Viper.GetString(config.Flag.Service.Installation.EnvironmentURLTemplate),
+			MaxTimeout:             config.Viper.GetDuration(config.Flag.Service.Deployer.MaxTimeout),
+			Rollback:               config.Viper.GetBool(config.Flag.Service.Deployer.Rollback),
+			WebhookBaseURL:         config.Viper.GetString(config.Flag.Service.Installation.WebhookBaseURL),
+		}
+
+		deployerService, err = deployer.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var repositoryFilter *filter.Filter
+	{
+		c := filter.Config{
+			AllowNames:  config.Viper.GetStringSlice(config.Flag.Service.Repository.Allow.Names),
+			AllowOwners: config.Viper.GetStringSlice(config.Flag.Service.Repository.Allow.Owners),
+			DenyNames:   config.Viper.GetStringSlice(config.Flag.Service.Repository.Deny.Names),
+			DenyOwners:  config.Viper.GetStringSlice(config.Flag.Service.Repository.Deny.Owners),
+		}
+
+		repositoryFilter, err = filter.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var versionService *version.Service
 	{
 		c := version.Config{
@@ -56,8 +315,73 @@ func New(config Config) (*Service, error) {
 		}
 	}
 
+	var jobStore *configmap.Store
+	{
+		c := configmap.Config{
+			K8sClient: config.K8sClient,
+			Logger:    config.Logger,
+
+			Namespace: config.Viper.GetString(config.Flag.Service.Store.Namespace),
+		}
+
+		jobStore, err = configmap.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var workerPool *worker.Pool
+	{
+		c := worker.Config{
+			Handler: deployerService.ProcessDeploymentEvent,
+			Logger:  config.Logger,
+			Store:   jobStore,
+
+			QueueSize:       config.Viper.GetInt(config.Flag.Service.Worker.QueueSize),
+			ShutdownTimeout: config.Viper.GetDuration(config.Flag.Service.Worker.ShutdownTimeout),
+			Workers:         config.Viper.GetInt(config.Flag.Service.Worker.Count),
+		}
+
+		workerPool, err = worker.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var appCollector *gc.Collector
+	if config.Viper.GetBool(config.Flag.Service.GC.Enabled) {
+		c := gc.Config{
+			Deployer:     deployerService,
+			GithubClient: githubClient,
+			K8sClient:    config.K8sClient,
+			Logger:       config.Logger,
+			Worker:       workerPool,
+
+			// The global dry run must not touch App CRs either, so it
+			// implies the dry run of the collector.
+			DryRun:   config.Viper.GetBool(config.Flag.Service.GC.DryRun) || config.Viper.GetBool(config.Flag.Service.DryRun),
+			Interval: config.Viper.GetDuration(config.Flag.Service.GC.Interval),
+			TTL:      config.Viper.GetDuration(config.Flag.Service.GC.TTL),
+		}
+
+		appCollector, err = gc.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	s := &Service{
-		Version: versionService,
+		AppWatcher: appWatcher,
+		Dedupe:     dedupeCache,
+		Deliveries: deliveryRecorder,
+		Deployer:   deployerService,
+		Filter:     repositoryFilter,
+		GC:         appCollector,
+		History:    historyRecorder,
+		Reporter:   statusReporter,
+		Tracker:    deploymentTracker,
+		Version:    versionService,
+		Worker:     workerPool,
 	}
 
 	return s, nil
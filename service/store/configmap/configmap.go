@@ -0,0 +1,149 @@
+// Package configmap implements a deployment job store which keeps every job in
+// its own ConfigMap.
+package configmap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/giantswarm/k8sclient/v5/pkg/k8sclient"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-github/v32/github"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/giantswarm/app-checker/pkg/label"
+	"github.com/giantswarm/app-checker/pkg/project"
+	"github.com/giantswarm/app-checker/service/store"
+)
+
+const (
+	eventKey = "event"
+	jobLabel = "app-checker.giantswarm.io/deployment-job"
+)
+
+type Config struct {
+	K8sClient k8sclient.Interface
+	Logger    micrologger.Logger
+
+	Namespace string
+}
+
+type Store struct {
+	k8sClient k8sclient.Interface
+	logger    micrologger.Logger
+
+	namespace string
+}
+
+func New(config Config) (*Store, error) {
+	if config.K8sClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.K8sClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.Namespace == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Namespace must not be empty", config)
+	}
+
+	s := &Store{
+		k8sClient: config.K8sClient,
+		logger:    config.Logger,
+
+		namespace: config.Namespace,
+	}
+
+	return s, nil
+}
+
+func (s *Store) Delete(ctx context.Context, jobID string) error {
+	err := s.k8sClient.K8sClient().CoreV1().ConfigMaps(s.namespace).Delete(ctx, name(jobID), metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		// fall through
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (s *Store) List(ctx context.Context) ([]*github.DeploymentEvent, error) {
+	lo := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=true", jobLabel),
+	}
+
+	list, err := s.k8sClient.K8sClient().CoreV1().ConfigMaps(s.namespace).List(ctx, lo)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var events []*github.DeploymentEvent
+	for _, cm := range list.Items {
+		var event github.DeploymentEvent
+
+		err = json.Unmarshal([]byte(cm.Data[eventKey]), &event)
+		if err != nil {
+			// A job which cannot be decoded can never be processed, so we drop
+			// it instead of failing every boot.
+			s.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("dropping undecodable deployment job %#q", cm.Name), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		events = append(events, &event)
+	}
+
+	return events, nil
+}
+
+func (s *Store) Put(ctx context.Context, event *github.DeploymentEvent) error {
+	if event.GetDeployment().GetID() == 0 {
+		return microerror.Maskf(invalidJobError, "deployment ID must not be empty")
+	}
+
+	b, err := json.Marshal(event)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name(store.JobID(event)),
+			Namespace: s.namespace,
+			Labels: map[string]string{
+				jobLabel:        "true",
+				label.ManagedBy: label.ManagedByValue(),
+			},
+		},
+		Data: map[string]string{
+			eventKey: string(b),
+		},
+	}
+
+	_, err = s.k8sClient.K8sClient().CoreV1().ConfigMaps(s.namespace).Create(ctx, cm, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		current, err := s.k8sClient.K8sClient().CoreV1().ConfigMaps(s.namespace).Get(ctx, cm.Name, metav1.GetOptions{})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+
+		cm.ResourceVersion = current.ResourceVersion
+
+		_, err = s.k8sClient.K8sClient().CoreV1().ConfigMaps(s.namespace).Update(ctx, cm, metav1.UpdateOptions{})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	} else if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func name(jobID string) string {
+	return fmt.Sprintf("%s-deployment-%s", project.Name(), jobID)
+}
@@ -0,0 +1,21 @@
+package configmap
+
+import "github.com/giantswarm/microerror"
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var invalidJobError = &microerror.Error{
+	Kind: "invalidJobError",
+}
+
+// IsInvalidJob asserts invalidJobError.
+func IsInvalidJob(err error) bool {
+	return microerror.Cause(err) == invalidJobError
+}
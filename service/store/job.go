@@ -0,0 +1,33 @@
+package store
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+)
+
+const (
+	// defaultTask is the GitHub deployment task of regular deployments.
+	defaultTask = "deploy"
+)
+
+var (
+	taskReplacer = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// JobID returns the ID of the job of the given event. Removals reuse the
+// GitHub deployment they report to, so the ID includes the deployment task to
+// keep a removal from overwriting a job of the same deployment. Jobs of
+// regular deployments are keyed by the bare deployment ID.
+func JobID(event *github.DeploymentEvent) string {
+	id := strconv.FormatInt(event.GetDeployment().GetID(), 10)
+
+	task := strings.ToLower(event.GetDeployment().GetTask())
+	if task == "" || task == defaultTask {
+		return id
+	}
+
+	return id + "-" + strings.Trim(taskReplacer.ReplaceAllString(task, "-"), "-")
+}
@@ -0,0 +1,23 @@
+// Package store defines how accepted deployment jobs are persisted so they
+// survive restarts of app-checker.
+package store
+
+import (
+	"context"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// Interface persists accepted GitHub deployment events until they are
+// processed completely.
+type Interface interface {
+	// Delete removes the job with the given job ID. Deleting a job which does
+	// not exist is not an error.
+	Delete(ctx context.Context, jobID string) error
+	// List returns the events of all jobs which were accepted but not yet
+	// processed completely.
+	List(ctx context.Context) ([]*github.DeploymentEvent, error)
+	// Put persists the job of the given event, overwriting any job with the
+	// same job ID.
+	Put(ctx context.Context, event *github.DeploymentEvent) error
+}
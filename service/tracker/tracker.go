@@ -0,0 +1,145 @@
+// Package tracker keeps an in-memory record of the most recently processed
+// deployments and the statuses reported for them.
+package tracker
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// MaxDeployments is the number of deployments kept. The least recently
+	// updated deployments are forgotten first.
+	MaxDeployments int
+}
+
+type Tracker struct {
+	maxDeployments int
+
+	deployments map[int64]*Deployment
+	mutex       sync.Mutex
+}
+
+func New(config Config) (*Tracker, error) {
+	if config.MaxDeployments <= 0 {
+		config.MaxDeployments = 1000
+	}
+
+	t := &Tracker{
+		maxDeployments: config.MaxDeployments,
+
+		deployments: map[int64]*Deployment{},
+	}
+
+	return t, nil
+}
+
+// Track records the given deployment, keeping the statuses of an already
+// tracked deployment with the same ID.
+func (t *Tracker) Track(d Deployment) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+
+	current, ok := t.deployments[d.ID]
+	if ok {
+		d.CreatedAt = current.CreatedAt
+		d.Statuses = current.Statuses
+	} else {
+		d.CreatedAt = now
+	}
+	d.UpdatedAt = now
+
+	t.deployments[d.ID] = &d
+	t.evict()
+}
+
+// AddStatus appends a status to the tracked deployment with the given ID.
+// Statuses of untracked deployments are ignored.
+func (t *Tracker) AddStatus(id int64, state, description string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	d, ok := t.deployments[id]
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+
+	d.Statuses = append(d.Statuses, Status{
+		State:       state,
+		Description: description,
+		Time:        now,
+	})
+	d.UpdatedAt = now
+}
+
+// SetDiff records the App CR changes of the dry run with the given ID.
+// Untracked deployments are ignored.
+func (t *Tracker) SetDiff(id int64, diff []string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	d, ok := t.deployments[id]
+	if !ok {
+		return
+	}
+
+	d.Diff = diff
+	d.UpdatedAt = time.Now()
+}
+
+// Get returns a copy of the tracked deployment with the given ID.
+func (t *Tracker) Get(id int64) (Deployment, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	d, ok := t.deployments[id]
+	if !ok {
+		return Deployment{}, false
+	}
+
+	return clone(d), true
+}
+
+// List returns copies of all tracked deployments, most recently updated
+// first.
+func (t *Tracker) List() []Deployment {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	list := make([]Deployment, 0, len(t.deployments))
+	for _, d := range t.deployments {
+		list = append(list, clone(d))
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].UpdatedAt.After(list[j].UpdatedAt)
+	})
+
+	return list
+}
+
+func (t *Tracker) evict() {
+	for len(t.deployments) > t.maxDeployments {
+		var oldest *Deployment
+		for _, d := range t.deployments {
+			if oldest == nil || d.UpdatedAt.Before(oldest.UpdatedAt) {
+				oldest = d
+			}
+		}
+
+		delete(t.deployments, oldest.ID)
+	}
+}
+
+func clone(d *Deployment) Deployment {
+	c := *d
+	c.Diff = append([]string(nil), d.Diff...)
+	c.Statuses = append([]Status(nil), d.Statuses...)
+
+	return c
+}
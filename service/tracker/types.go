@@ -0,0 +1,42 @@
+package tracker
+
+import "time"
+
+// Deployment is the record of a GitHub deployment processed by app-checker.
+type Deployment struct {
+	ID         int64  `json:"id"`
+	Owner      string `json:"owner"`
+	Repository string `json:"repository"`
+	Ref        string `json:"ref"`
+	SHA        string `json:"sha"`
+	// InstallationID is the GitHub App installation which delivered the
+	// deployment, if any.
+	InstallationID int64 `json:"installationID,omitempty"`
+
+	App App `json:"app"`
+	// DryRun marks deployments which only computed the App CR changes they
+	// would make.
+	DryRun bool `json:"dryRun,omitempty"`
+	// Diff lists the App CR changes of a dry run.
+	Diff []string `json:"diff,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Statuses  []Status  `json:"statuses"`
+}
+
+// App references the App CR a deployment is deployed to.
+type App struct {
+	Catalog   string `json:"catalog"`
+	Cluster   string `json:"cluster,omitempty"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   string `json:"version"`
+}
+
+// Status is a GitHub deployment status reported for a deployment.
+type Status struct {
+	State       string    `json:"state"`
+	Description string    `json:"description"`
+	Time        time.Time `json:"time"`
+}
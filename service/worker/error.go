@@ -0,0 +1,30 @@
+package worker
+
+import "github.com/giantswarm/microerror"
+
+var invalidConfigError = &microerror.Error{
+	Kind: "invalidConfigError",
+}
+
+// IsInvalidConfig asserts invalidConfigError.
+func IsInvalidConfig(err error) bool {
+	return microerror.Cause(err) == invalidConfigError
+}
+
+var queueFullError = &microerror.Error{
+	Kind: "queueFullError",
+}
+
+// IsQueueFull asserts queueFullError.
+func IsQueueFull(err error) bool {
+	return microerror.Cause(err) == queueFullError
+}
+
+var shutdownError = &microerror.Error{
+	Kind: "shutdownError",
+}
+
+// IsShutdown asserts shutdownError.
+func IsShutdown(err error) bool {
+	return microerror.Cause(err) == shutdownError
+}
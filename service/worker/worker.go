@@ -0,0 +1,310 @@
+// Package worker implements a bounded pool of workers which process GitHub
+// deployment events asynchronously, decoupled from the webhook HTTP handler.
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-github/v32/github"
+
+	"github.com/giantswarm/app-checker/service/store"
+)
+
+// Handler processes a single GitHub deployment event.
+type Handler func(ctx context.Context, event *github.DeploymentEvent) error
+
+type Config struct {
+	Handler Handler
+	Logger  micrologger.Logger
+	Store   store.Interface
+
+	// QueueSize is the number of events which can be queued before Enqueue
+	// rejects further events.
+	QueueSize int
+	// ShutdownTimeout is the time Shutdown waits for queued and in-flight
+	// events to be processed before their contexts are cancelled.
+	ShutdownTimeout time.Duration
+	// Workers is the number of events processed concurrently.
+	Workers int
+}
+
+type Pool struct {
+	handler Handler
+	logger  micrologger.Logger
+	store   store.Interface
+
+	shutdownTimeout time.Duration
+	workers         int
+
+	bootOnce     sync.Once
+	cancel       context.CancelFunc
+	ctx          context.Context
+	inFlight     map[string]*github.DeploymentEvent
+	jobs         map[string]bool
+	mutex        sync.Mutex
+	queue        chan *github.DeploymentEvent
+	reserved     int
+	shutdownOnce sync.Once
+	stop         chan struct{}
+	stopped      bool
+	wg           sync.WaitGroup
+}
+
+func New(config Config) (*Pool, error) {
+	if config.Handler == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Handler must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Store == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Store must not be empty", config)
+	}
+
+	if config.QueueSize <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.QueueSize must be greater than zero", config)
+	}
+	if config.ShutdownTimeout <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.ShutdownTimeout must be greater than zero", config)
+	}
+	if config.Workers <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Workers must be greater than zero", config)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := &Pool{
+		handler: config.Handler,
+		logger:  config.Logger,
+		store:   config.Store,
+
+		shutdownTimeout: config.ShutdownTimeout,
+		workers:         config.Workers,
+
+		cancel:   cancel,
+		ctx:      ctx,
+		inFlight: map[string]*github.DeploymentEvent{},
+		jobs:     map[string]bool{},
+		queue:    make(chan *github.DeploymentEvent, config.QueueSize),
+		stop:     make(chan struct{}),
+	}
+
+	return p, nil
+}
+
+// Boot starts the workers draining the queue and resumes all jobs which were
+// persisted but not processed completely before the last shutdown.
+func (p *Pool) Boot() {
+	p.bootOnce.Do(func() {
+		for i := 0; i < p.workers; i++ {
+			p.wg.Add(1)
+			go p.work()
+		}
+
+		go p.resume()
+	})
+}
+
+// Enqueue persists the given event and queues it for processing without
+// blocking. Events of jobs which are queued or in flight already are accepted
+// without queueing them again. It returns a queueFullError when the queue is
+// at capacity and a shutdownError once the pool is shutting down.
+func (p *Pool) Enqueue(ctx context.Context, event *github.DeploymentEvent) error {
+	jobID := store.JobID(event)
+
+	// A queue slot is reserved before persisting the job, so concurrent
+	// deliveries do not wait for each other's Kubernetes API requests.
+	p.mutex.Lock()
+	if p.stopped {
+		p.mutex.Unlock()
+		return microerror.Maskf(shutdownError, "not accepting deployment %d", event.GetDeployment().GetID())
+	}
+	if p.jobs[jobID] {
+		p.mutex.Unlock()
+		p.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("job %#q of deployment %d is queued already", jobID, event.GetDeployment().GetID()))
+		return nil
+	}
+	if len(p.queue)+p.reserved >= cap(p.queue) {
+		p.mutex.Unlock()
+		return microerror.Maskf(queueFullError, "not accepting deployment %d, %d events queued", event.GetDeployment().GetID(), len(p.queue))
+	}
+	p.jobs[jobID] = true
+	p.reserved++
+	p.mutex.Unlock()
+
+	err := p.store.Put(ctx, event)
+
+	p.mutex.Lock()
+	p.reserved--
+
+	if err != nil {
+		delete(p.jobs, jobID)
+		p.mutex.Unlock()
+		return microerror.Mask(err)
+	}
+	if p.stopped {
+		// The workers may have drained the queue already. The job stays
+		// persisted and is resumed on the next boot.
+		delete(p.jobs, jobID)
+		p.mutex.Unlock()
+		return microerror.Maskf(shutdownError, "not processing deployment %d before the next boot", event.GetDeployment().GetID())
+	}
+
+	select {
+	case p.queue <- event:
+		p.mutex.Unlock()
+		return nil
+	default:
+	}
+
+	// Resumed jobs may have taken the free slot in the meantime. The job is
+	// rejected, so it must not be resumed on the next boot either.
+	delete(p.jobs, jobID)
+	queued := len(p.queue)
+	p.mutex.Unlock()
+
+	err = p.store.Delete(ctx, jobID)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return microerror.Maskf(queueFullError, "not accepting deployment %d, %d events queued", event.GetDeployment().GetID(), queued)
+}
+
+// InFlight returns the number of events currently being processed.
+func (p *Pool) InFlight() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return len(p.inFlight)
+}
+
+// Queued returns the number of events waiting for a free worker.
+func (p *Pool) Queued() int {
+	return len(p.queue)
+}
+
+// Shutdown stops accepting new events and waits for queued and in-flight
+// events to be processed. Events still running after the shutdown timeout
+// get their context cancelled and stay persisted to be resumed on the next
+// boot.
+func (p *Pool) Shutdown() {
+	p.shutdownOnce.Do(func() {
+		p.mutex.Lock()
+		p.stopped = true
+		close(p.stop)
+		p.mutex.Unlock()
+
+		p.logger.Log("level", "debug", "message", fmt.Sprintf("draining %d queued and %d in-flight deployment events", p.Queued(), p.InFlight()))
+
+		done := make(chan struct{})
+		go func() {
+			p.wg.Wait()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			p.logger.Log("level", "debug", "message", "drained deployment events")
+		case <-time.After(p.shutdownTimeout):
+			p.logger.Log("level", "warning", "message", fmt.Sprintf("cancelling %d in-flight deployment events after %s", p.InFlight(), p.shutdownTimeout))
+			p.cancel()
+			<-done
+		}
+
+		p.cancel()
+	})
+}
+
+func (p *Pool) process(event *github.DeploymentEvent) {
+	id := event.GetDeployment().GetID()
+	jobID := store.JobID(event)
+
+	p.mutex.Lock()
+	p.inFlight[jobID] = event
+	p.mutex.Unlock()
+
+	defer func() {
+		p.mutex.Lock()
+		delete(p.inFlight, jobID)
+		delete(p.jobs, jobID)
+		p.mutex.Unlock()
+	}()
+
+	err := p.handler(p.ctx, event)
+	if p.ctx.Err() != nil {
+		// The pool was shut down while the event was processed. We keep the
+		// job persisted so it is resumed on the next boot.
+		p.logger.Log("level", "warning", "message", fmt.Sprintf("interrupted deployment %d of repository %#q", id, event.GetRepo().GetFullName()))
+		return
+	} else if err != nil {
+		p.logger.Log("level", "error", "message", fmt.Sprintf("failed to process deployment %d of repository %#q", id, event.GetRepo().GetFullName()), "stack", fmt.Sprintf("%#v", err))
+	}
+
+	err = p.store.Delete(context.Background(), jobID)
+	if err != nil {
+		p.logger.Log("level", "error", "message", fmt.Sprintf("failed to delete job %#q of deployment %d", jobID, id), "stack", fmt.Sprintf("%#v", err))
+	}
+}
+
+func (p *Pool) resume() {
+	events, err := p.store.List(p.ctx)
+	if err != nil {
+		p.logger.Log("level", "error", "message", "failed to list persisted deployment jobs", "stack", fmt.Sprintf("%#v", err))
+		return
+	}
+
+	if len(events) > 0 {
+		p.logger.Log("level", "debug", "message", fmt.Sprintf("resuming %d persisted deployment jobs", len(events)))
+	}
+
+	for _, event := range events {
+		jobID := store.JobID(event)
+
+		// Deliveries received since the boot may have queued the job already.
+		p.mutex.Lock()
+		queued := p.jobs[jobID]
+		p.jobs[jobID] = true
+		p.mutex.Unlock()
+
+		if queued {
+			continue
+		}
+
+		select {
+		case p.queue <- event:
+			p.logger.Log("level", "debug", "message", fmt.Sprintf("resumed deployment %d of repository %#q", event.GetDeployment().GetID(), event.GetRepo().GetFullName()))
+		case <-p.stop:
+			p.mutex.Lock()
+			delete(p.jobs, jobID)
+			p.mutex.Unlock()
+			return
+		}
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case event := <-p.queue:
+			p.process(event)
+		case <-p.stop:
+			// Drain the events queued before the shutdown.
+			for {
+				select {
+				case event := <-p.queue:
+					p.process(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger/microloggertest"
+	"github.com/google/go-github/v32/github"
+
+	"github.com/giantswarm/app-checker/service/store"
+)
+
+func Test_Pool_Enqueue(t *testing.T) {
+	testCases := []struct {
+		name string
+		// queued are the events enqueued before the tested one.
+		queued []*github.DeploymentEvent
+		// fill fills the queue while the tested event is persisted, like
+		// resumed jobs taking the free slot do.
+		fill     bool
+		putErr   error
+		shutdown bool
+		// expectedJobs are the job IDs persisted afterwards.
+		expectedJobs []string
+		// expectedQueued is the number of queued events afterwards.
+		expectedQueued int
+		errorMatcher   func(error) bool
+	}{
+		{
+			name:           "case 0: event queued",
+			expectedJobs:   []string{"1"},
+			expectedQueued: 1,
+		},
+		{
+			name:           "case 1: queue full",
+			queued:         []*github.DeploymentEvent{testEvent(2, ""), testEvent(3, "")},
+			expectedJobs:   []string{"2", "3"},
+			expectedQueued: 2,
+			errorMatcher:   IsQueueFull,
+		},
+		{
+			name:           "case 2: queue filled while persisting drops the job",
+			fill:           true,
+			expectedJobs:   []string{},
+			expectedQueued: 2,
+			errorMatcher:   IsQueueFull,
+		},
+		{
+			name:           "case 3: event queued already",
+			queued:         []*github.DeploymentEvent{testEvent(1, "")},
+			expectedJobs:   []string{"1"},
+			expectedQueued: 1,
+		},
+		{
+			name:           "case 4: removal of queued deployment queued separately",
+			queued:         []*github.DeploymentEvent{testEvent(1, "remove")},
+			expectedJobs:   []string{"1", "1-remove"},
+			expectedQueued: 2,
+		},
+		{
+			name:           "case 5: pool shut down",
+			shutdown:       true,
+			expectedJobs:   []string{},
+			expectedQueued: 0,
+			errorMatcher:   IsShutdown,
+		},
+		{
+			name:           "case 6: persisting fails",
+			putErr:         errors.New("test error"),
+			expectedJobs:   []string{},
+			expectedQueued: 0,
+			errorMatcher:   func(err error) bool { return err != nil },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestStore()
+
+			p, err := New(Config{
+				Handler: func(ctx context.Context, event *github.DeploymentEvent) error { return nil },
+				Logger:  microloggertest.New(),
+				Store:   s,
+
+				QueueSize:       2,
+				ShutdownTimeout: time.Second,
+				Workers:         1,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, event := range tc.queued {
+				err = p.Enqueue(context.Background(), event)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.fill {
+				s.onPut = func() {
+					p.queue <- testEvent(2, "")
+					p.queue <- testEvent(3, "")
+				}
+			}
+			s.putErr = tc.putErr
+			if tc.shutdown {
+				p.Shutdown()
+			}
+
+			err = p.Enqueue(context.Background(), testEvent(1, ""))
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			jobs := s.jobIDs()
+			if !equalStrings(jobs, tc.expectedJobs) {
+				t.Fatalf("jobs == %v, want %v", jobs, tc.expectedJobs)
+			}
+			if p.Queued() != tc.expectedQueued {
+				t.Fatalf("queued == %d, want %d", p.Queued(), tc.expectedQueued)
+			}
+		})
+	}
+}
+
+func Test_Pool_resume(t *testing.T) {
+	testCases := []struct {
+		name string
+		// persisted are the events persisted before the boot.
+		persisted []*github.DeploymentEvent
+		// queued are the events enqueued before resuming.
+		queued []*github.DeploymentEvent
+		// expectedQueued are the job IDs queued afterwards, in order.
+		expectedQueued []string
+	}{
+		{
+			name:           "case 0: nothing persisted",
+			expectedQueued: []string{},
+		},
+		{
+			name:           "case 1: persisted jobs resumed",
+			persisted:      []*github.DeploymentEvent{testEvent(1, ""), testEvent(2, "remove")},
+			expectedQueued: []string{"1", "2-remove"},
+		},
+		{
+			name:           "case 2: job queued by a redelivery not resumed again",
+			persisted:      []*github.DeploymentEvent{testEvent(1, ""), testEvent(2, "")},
+			queued:         []*github.DeploymentEvent{testEvent(2, "")},
+			expectedQueued: []string{"2", "1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestStore()
+			for _, event := range tc.persisted {
+				err := s.Put(context.Background(), event)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			p, err := New(Config{
+				Handler: func(ctx context.Context, event *github.DeploymentEvent) error { return nil },
+				Logger:  microloggertest.New(),
+				Store:   s,
+
+				QueueSize:       10,
+				ShutdownTimeout: time.Second,
+				Workers:         1,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, event := range tc.queued {
+				err = p.Enqueue(context.Background(), event)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			p.resume()
+
+			queued := []string{}
+			for len(p.queue) > 0 {
+				queued = append(queued, store.JobID(<-p.queue))
+			}
+
+			if len(queued) != len(tc.expectedQueued) {
+				t.Fatalf("queued == %v, want %v", queued, tc.expectedQueued)
+			}
+			for i := range queued {
+				if queued[i] != tc.expectedQueued[i] {
+					t.Fatalf("queued == %v, want %v", queued, tc.expectedQueued)
+				}
+			}
+		})
+	}
+}
+
+func Test_Pool_Shutdown(t *testing.T) {
+	testCases := []struct {
+		name   string
+		events []*github.DeploymentEvent
+		// block makes the handler wait for its context to be cancelled.
+		block bool
+		// expectedProcessed are the job IDs processed completely.
+		expectedProcessed []string
+		// expectedJobs are the job IDs still persisted afterwards.
+		expectedJobs []string
+	}{
+		{
+			name:              "case 0: queued events drained",
+			events:            []*github.DeploymentEvent{testEvent(1, ""), testEvent(2, ""), testEvent(3, "")},
+			expectedProcessed: []string{"1", "2", "3"},
+			expectedJobs:      []string{},
+		},
+		{
+			name:              "case 1: events running past the timeout stay persisted",
+			events:            []*github.DeploymentEvent{testEvent(1, "")},
+			block:             true,
+			expectedProcessed: []string{},
+			expectedJobs:      []string{"1"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var mutex sync.Mutex
+			processed := []string{}
+
+			handler := func(ctx context.Context, event *github.DeploymentEvent) error {
+				if tc.block {
+					<-ctx.Done()
+					return ctx.Err()
+				}
+
+				mutex.Lock()
+				processed = append(processed, store.JobID(event))
+				mutex.Unlock()
+
+				return nil
+			}
+
+			s := newTestStore()
+
+			p, err := New(Config{
+				Handler: handler,
+				Logger:  microloggertest.New(),
+				Store:   s,
+
+				QueueSize:       10,
+				ShutdownTimeout: 100 * time.Millisecond,
+				Workers:         2,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, event := range tc.events {
+				err = p.Enqueue(context.Background(), event)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			p.Boot()
+			p.Shutdown()
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			if !equalStrings(processed, tc.expectedProcessed) {
+				t.Fatalf("processed == %v, want %v", processed, tc.expectedProcessed)
+			}
+
+			jobs := s.jobIDs()
+			if !equalStrings(jobs, tc.expectedJobs) {
+				t.Fatalf("jobs == %v, want %v", jobs, tc.expectedJobs)
+			}
+
+			err = p.Enqueue(context.Background(), testEvent(4, ""))
+			if !IsShutdown(err) {
+				t.Fatalf("error == %#v, want matching", err)
+			}
+		})
+	}
+}
+
+// testStore is an in-memory store.Interface.
+type testStore struct {
+	mutex sync.Mutex
+	jobs  map[string]*github.DeploymentEvent
+	order []string
+
+	// onPut is called while persisting a job.
+	onPut  func()
+	putErr error
+}
+
+func newTestStore() *testStore {
+	return &testStore{
+		jobs: map[string]*github.DeploymentEvent{},
+	}
+}
+
+func (s *testStore) Delete(ctx context.Context, jobID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.jobs, jobID)
+
+	return nil
+}
+
+func (s *testStore) List(ctx context.Context) ([]*github.DeploymentEvent, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var events []*github.DeploymentEvent
+	for _, id := range s.order {
+		if event, ok := s.jobs[id]; ok {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+func (s *testStore) Put(ctx context.Context, event *github.DeploymentEvent) error {
+	if s.onPut != nil {
+		s.onPut()
+	}
+	if s.putErr != nil {
+		return s.putErr
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := store.JobID(event)
+	if _, ok := s.jobs[id]; !ok {
+		s.order = append(s.order, id)
+	}
+	s.jobs[id] = event
+
+	return nil
+}
+
+func (s *testStore) jobIDs() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ids := []string{}
+	for id := range s.jobs {
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// equalStrings returns whether a and b hold the same strings in any order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	a = append([]string{}, a...)
+	b = append([]string{}, b...)
+	sort.Strings(a)
+	sort.Strings(b)
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func testEvent(id int64, task string) *github.DeploymentEvent {
+	return &github.DeploymentEvent{
+		Deployment: &github.Deployment{
+			ID:   github.Int64(id),
+			Task: github.String(task),
+		},
+		Repo: &github.Repository{
+			FullName: github.String("giantswarm/app-checker"),
+			Name:     github.String("app-checker"),
+		},
+	}
+}